@@ -0,0 +1,118 @@
+package asciiart
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/gif"
+	"io"
+	"time"
+)
+
+// Single fully composited image of an animation and how long it stays on screen
+type Frame struct {
+	Img   image.Image
+	Delay time.Duration
+}
+
+// Sequence of frames, e.g. decoded from an animated GIF
+type Animation struct {
+	Frames []Frame
+	Loops  int // number of times to play through the frames, 0 to repeat forever
+}
+
+// Result of converting one frame of an animation
+type ResultFrame struct {
+	Result *Result
+	Delay  time.Duration
+}
+
+// Decode every frame of a GIF, compositing each one onto the canvas left by the previous frames according to
+// their disposal methods so that every Frame is a complete image
+func DecodeGIF(r io.Reader) (*Animation, error) {
+	g, err := gif.DecodeAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(g.Image) == 0 {
+		return nil, fmt.Errorf("gif has no frames")
+	}
+
+	canvasBounds := image.Rect(0, 0, g.Config.Width, g.Config.Height)
+	if canvasBounds.Empty() {
+		canvasBounds = g.Image[0].Bounds()
+	}
+	canvas := image.NewRGBA(canvasBounds)
+
+	a := &Animation{Frames: make([]Frame, len(g.Image))}
+	switch {
+	case g.LoopCount < 0:
+		a.Loops = 1
+	case g.LoopCount > 0:
+		a.Loops = g.LoopCount + 1
+	}
+
+	for i, p := range g.Image {
+		var disposal byte
+		if i < len(g.Disposal) {
+			disposal = g.Disposal[i]
+		}
+
+		var previous *image.RGBA
+		if disposal == gif.DisposalPrevious {
+			previous = image.NewRGBA(canvasBounds)
+			copy(previous.Pix, canvas.Pix)
+		}
+
+		draw.Draw(canvas, p.Bounds(), p, p.Bounds().Min, draw.Over)
+
+		frame := image.NewRGBA(canvasBounds)
+		copy(frame.Pix, canvas.Pix)
+		a.Frames[i] = Frame{Img: frame}
+		if i < len(g.Delay) {
+			a.Frames[i].Delay = time.Duration(g.Delay[i]) * 10 * time.Millisecond
+		}
+
+		switch disposal {
+		case gif.DisposalBackground:
+			draw.Draw(canvas, p.Bounds(), image.Transparent, image.Point{}, draw.Src)
+		case gif.DisposalPrevious:
+			canvas = previous
+		}
+	}
+
+	return a, nil
+}
+
+// Decode an animated GIF into all of its frames, or any other registered image format into a single frame
+func DecodeAnimation(r io.Reader) (*Animation, error) {
+	br := bufio.NewReader(r)
+	magic, _ := br.Peek(4)
+	if bytes.Equal(magic, []byte("GIF8")) {
+		return DecodeGIF(br)
+	}
+
+	img, _, err := image.Decode(br)
+	if err != nil {
+		return nil, err
+	}
+	return &Animation{Frames: []Frame{{Img: img}}, Loops: 1}, nil
+}
+
+// Convert every frame of an animation with the converter's settings, ignoring its Img
+func (c *Converter) ConvertAnimation(a *Animation) ([]ResultFrame, error) {
+	frames := make([]ResultFrame, len(a.Frames))
+	for i, f := range a.Frames {
+		fc := *c
+		fc.Img = f.Img
+
+		r, err := fc.Convert()
+		if err != nil {
+			return nil, fmt.Errorf("frame %d: %w", i, err)
+		}
+		frames[i] = ResultFrame{Result: r, Delay: f.Delay}
+	}
+	return frames, nil
+}
@@ -0,0 +1,117 @@
+package asciiart
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/gif"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestDecodeGIFDisposal(t *testing.T) {
+	transparent := color.RGBA{}
+	red := color.RGBA{255, 0, 0, 255}
+	blue := color.RGBA{0, 0, 255, 255}
+	palette := color.Palette{transparent, red, blue}
+
+	fill := func(r image.Rectangle, index uint8) *image.Paletted {
+		p := image.NewPaletted(r, palette)
+		for i := range p.Pix {
+			p.Pix[i] = index
+		}
+		return p
+	}
+
+	g := &gif.GIF{
+		Image: []*image.Paletted{
+			fill(image.Rect(0, 0, 4, 4), 1),
+			fill(image.Rect(0, 0, 2, 2), 2),
+			fill(image.Rect(3, 3, 4, 4), 2),
+			fill(image.Rect(2, 2, 3, 3), 2),
+		},
+		Delay:    []int{10, 20, 0, 5},
+		Disposal: []byte{gif.DisposalNone, gif.DisposalBackground, gif.DisposalPrevious, gif.DisposalNone},
+		Config:   image.Config{ColorModel: palette, Width: 4, Height: 4},
+	}
+
+	var buf bytes.Buffer
+	err := gif.EncodeAll(&buf, g)
+	if err != nil {
+		t.Fatalf("Failed to encode gif: %v", err)
+	}
+
+	a, err := DecodeAnimation(&buf)
+	if err != nil {
+		t.Fatalf("Failed to decode gif: %v", err)
+	}
+
+	if len(a.Frames) != 4 {
+		t.Fatalf("got %d frames, want 4", len(a.Frames))
+	}
+	if a.Loops != 0 {
+		t.Errorf("got %d loops, want 0", a.Loops)
+	}
+
+	testData := []struct {
+		frame int
+		x, y  int
+		want  color.RGBA
+	}{
+		{0, 0, 0, red},
+		{0, 3, 3, red},
+		{1, 0, 0, blue},
+		{1, 2, 2, red},
+		{2, 0, 0, transparent}, // cleared by frame 1's background disposal
+		{2, 3, 3, blue},
+		{2, 2, 0, red},
+		{3, 3, 3, red}, // restored by frame 2's previous disposal
+		{3, 2, 2, blue},
+		{3, 1, 1, transparent},
+	}
+
+	for _, d := range testData {
+		res := color.RGBAModel.Convert(a.Frames[d.frame].Img.At(d.x, d.y))
+		if res != d.want {
+			t.Errorf("frame %d at %d,%d: got %v, want %v", d.frame, d.x, d.y, res, d.want)
+		}
+	}
+
+	delays := []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 0, 50 * time.Millisecond}
+	for i, want := range delays {
+		if a.Frames[i].Delay != want {
+			t.Errorf("frame %d: got delay %v, want %v", i, a.Frames[i].Delay, want)
+		}
+	}
+}
+
+func TestConvertAnimation(t *testing.T) {
+	file, err := os.Open(filepath.Join("..", "testdata", "sample_image_0.png"))
+	if err != nil {
+		t.Fatalf("Failed to open file: %v", err)
+	}
+	defer file.Close()
+
+	a, err := DecodeAnimation(file)
+	if err != nil {
+		t.Fatalf("Failed to decode image: %v", err)
+	}
+	if len(a.Frames) != 1 || a.Loops != 1 {
+		t.Fatalf("got %d frames and %d loops, want a single frame played once", len(a.Frames), a.Loops)
+	}
+
+	a.Frames = append(a.Frames, Frame{Img: a.Frames[0].Img, Delay: time.Second})
+	frames, err := NewConverter(nil, WithWidth(40)).ConvertAnimation(a)
+	if err != nil {
+		t.Fatalf("Failed to convert animation: %v", err)
+	}
+
+	if len(frames) != 2 || frames[1].Delay != time.Second {
+		t.Fatalf("got %d frames, want 2 with the source delays", len(frames))
+	}
+	if frames[0].Result.Width() != 40 || frames[1].Result.Height() != frames[0].Result.Height() {
+		t.Errorf("got %d x %d and %d x %d frames", frames[0].Result.Width(), frames[0].Result.Height(), frames[1].Result.Width(), frames[1].Result.Height())
+	}
+}
@@ -0,0 +1,98 @@
+package asciiart
+
+import (
+	"bufio"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"strings"
+)
+
+type ColorMode int
+
+const (
+	NoColor   ColorMode = iota
+	Truecolor           // 24-bit "ESC[38;2;R;G;Bm" foreground escapes
+	ANSI256             // xterm-256 color cube and grayscale ramp
+	ANSI16              // basic 16 color ANSI palette
+)
+
+const ansiReset = "\x1b[0m"
+
+// Parse a color mode name as accepted by the CLI
+func ParseColorMode(name string) (ColorMode, error) {
+	switch name {
+	case "", "none":
+		return NoColor, nil
+	case "truecolor", "24bit":
+		return Truecolor, nil
+	case "256":
+		return ANSI256, nil
+	case "16":
+		return ANSI16, nil
+	default:
+		return NoColor, fmt.Errorf("unknown color mode: %q", name)
+	}
+}
+
+// Escape sequence setting the foreground to c in the given mode
+func ansiForeground(c color.RGBA, mode ColorMode) string {
+	switch mode {
+	case Truecolor:
+		return fmt.Sprintf("\x1b[38;2;%d;%d;%dm", c.R, c.G, c.B)
+	case ANSI256, ANSI16:
+		p := paletteFor(mode)
+		return p.sgr(p.nearest(rgbToLab(c)))
+	default:
+		return ""
+	}
+}
+
+// Builds one row of ANSI colored output, only emitting an escape when the color changes from the previous cell
+func ansiRow(row []rune, colors *image.RGBA, y int, mode ColorMode) string {
+	var sb strings.Builder
+	last := ""
+	for x, char := range row {
+		esc := ansiForeground(colors.RGBAAt(colors.Rect.Min.X+x, colors.Rect.Min.Y+y), mode)
+		if esc != last {
+			sb.WriteString(esc)
+			last = esc
+		}
+		sb.WriteRune(char)
+	}
+	if last != "" {
+		sb.WriteString(ansiReset)
+	}
+	return sb.String()
+}
+
+// Renders the characters with ANSI foreground escapes taken from the result's cell colors
+type ANSIRenderer struct {
+	Mode   ColorMode
+	Dither bool // diffuse quantization error across cells in the 256 and 16 color modes
+}
+
+func (a ANSIRenderer) Render(w io.Writer, r *Result) error {
+	if a.Mode == NoColor {
+		return TextRenderer{}.Render(w, r)
+	}
+
+	colors, err := resultColors(r)
+	if err != nil {
+		return err
+	}
+	if a.Dither && paletteFor(a.Mode) != nil {
+		colors, err = DitherColors(colors, a.Mode)
+		if err != nil {
+			return err
+		}
+	}
+
+	bw := bufio.NewWriter(w)
+	for y, row := range r.Art {
+		bw.WriteString(ansiRow(row, colors, y, a.Mode))
+		bw.WriteByte('\n')
+	}
+	return bw.Flush()
+}
@@ -0,0 +1,77 @@
+package asciiart
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestParseColorMode(t *testing.T) {
+	testData := []struct {
+		name    string
+		want    ColorMode
+		wantErr bool
+	}{
+		{"", NoColor, false},
+		{"none", NoColor, false},
+		{"truecolor", Truecolor, false},
+		{"24bit", Truecolor, false},
+		{"256", ANSI256, false},
+		{"16", ANSI16, false},
+		{"rainbow", NoColor, true},
+	}
+
+	for _, d := range testData {
+		t.Run(d.name, func(t *testing.T) {
+			res, err := ParseColorMode(d.name)
+			if (err != nil) != d.wantErr {
+				t.Fatalf("got error %v, want error %t", err, d.wantErr)
+			}
+			if res != d.want {
+				t.Errorf("got %d, want %d", res, d.want)
+			}
+		})
+	}
+}
+
+func TestANSIRowTruecolor(t *testing.T) {
+	red := color.RGBA{255, 0, 0, 255}
+	blue := color.RGBA{0, 0, 255, 255}
+
+	colors := image.NewRGBA(image.Rect(0, 0, 4, 1))
+	colors.SetRGBA(0, 0, red)
+	colors.SetRGBA(1, 0, red)
+	colors.SetRGBA(2, 0, blue)
+	colors.SetRGBA(3, 0, red)
+
+	res := ansiRow([]rune("ab\\d"), colors, 0, Truecolor)
+	want := "\x1b[38;2;255;0;0mab\x1b[38;2;0;0;255m\\\x1b[38;2;255;0;0md\x1b[0m"
+	if res != want {
+		t.Errorf("got %q, want %q", res, want)
+	}
+}
+
+func TestANSIRenderer(t *testing.T) {
+	colors := image.NewRGBA(image.Rect(0, 0, 2, 2))
+	for i := range colors.Pix {
+		colors.Pix[i] = 255
+	}
+	r := &Result{Art: [][]rune{[]rune("ab"), []rune("cd")}, Colors: colors}
+
+	var buf bytes.Buffer
+	err := ANSIRenderer{Mode: Truecolor}.Render(&buf, r)
+	if err != nil {
+		t.Fatalf("Failed to render: %v", err)
+	}
+
+	want := "\x1b[38;2;255;255;255mab\x1b[0m\n\x1b[38;2;255;255;255mcd\x1b[0m\n"
+	if buf.String() != want {
+		t.Errorf("got %q, want %q", buf.String(), want)
+	}
+
+	r.Colors = nil
+	if err := (ANSIRenderer{Mode: ANSI16}).Render(&buf, r); err == nil {
+		t.Errorf("expected error rendering without colors")
+	}
+}
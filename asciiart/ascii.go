@@ -1,10 +1,10 @@
 package asciiart
 
 import (
-	"fmt"
 	"image"
 	"image/color"
 	"math"
+	"os"
 
 	"github.com/disintegration/gift"
 )
@@ -12,10 +12,10 @@ import (
 // Grayscale and Downscale
 func GrayDownscale(img image.Image, width int, squash float32) (*image.Gray, error) {
 	if width <= 0 {
-		return nil, fmt.Errorf("width must be positive")
+		return nil, optionError("width", width, "must be positive")
 	}
 	if squash <= 0 {
-		return nil, fmt.Errorf("horizontal scale must be positive")
+		return nil, optionError("squash", squash, "must be positive")
 	}
 	scale := float64(img.Bounds().Dx()) / float64(width) * float64(squash)
 	height := int(math.Floor(float64(img.Bounds().Dy()) / scale))
@@ -27,10 +27,28 @@ func GrayDownscale(img image.Image, width int, squash float32) (*image.Gray, err
 	return dst, nil
 }
 
+// Downscale to the same block geometry as GrayDownscale, keeping the average color of each block
+func ColorDownscale(img image.Image, width int, squash float32) (*image.RGBA, error) {
+	if width <= 0 {
+		return nil, optionError("width", width, "must be positive")
+	}
+	if squash <= 0 {
+		return nil, optionError("squash", squash, "must be positive")
+	}
+	scale := float64(img.Bounds().Dx()) / float64(width) * float64(squash)
+	height := int(math.Floor(float64(img.Bounds().Dy()) / scale))
+
+	g := gift.New(gift.Resize(width, height, gift.BoxResampling))
+	dst := image.NewRGBA(g.Bounds(img.Bounds()))
+	g.Draw(dst, img)
+
+	return dst, nil
+}
+
 // Converts a grayscale image to ASCII art
 func ConvertToASCIIArt(img image.Image, charset []rune) ([][]rune, error) {
 	if len(charset) == 0 {
-		return nil, fmt.Errorf("charset must not be empty")
+		return nil, optionError("charset", string(charset), "must not be empty")
 	}
 	bounds := img.Bounds()
 	width, height := bounds.Max.X, bounds.Max.Y
@@ -52,10 +70,5 @@ func ConvertToASCIIArt(img image.Image, charset []rune) ([][]rune, error) {
 
 // Prints the 2D ASCII art to the console
 func PrintASCIIArt(asciiArt [][]rune) {
-	for _, row := range asciiArt {
-		for _, char := range row {
-			fmt.Print(string(char))
-		}
-		fmt.Println()
-	}
+	TextRenderer{}.Render(os.Stdout, &Result{Art: asciiArt})
 }
@@ -122,3 +122,30 @@ func TestConvertToASCIIArt(t *testing.T) {
 		t.Logf("Image saved as ASCIIArt%d.txt", i)
 	}
 }
+
+func TestColorDownscale(t *testing.T) {
+	file, err := os.Open(filepath.Join("..", "testdata", "sample_image_0.png"))
+	if err != nil {
+		t.Fatalf("Failed to open file: %v", err)
+	}
+	defer file.Close()
+
+	img, _, err := image.Decode(file)
+	if err != nil {
+		t.Fatalf("Failed to decode image: %v", err)
+	}
+
+	g, err := GrayDownscale(img, 80, 2.3)
+	if err != nil {
+		t.Fatalf("Failed to grayscale and downscale: %v", err)
+	}
+
+	c, err := ColorDownscale(img, 80, 2.3)
+	if err != nil {
+		t.Fatalf("Failed to downscale colors: %v", err)
+	}
+
+	if c.Bounds() != g.Bounds() {
+		t.Errorf("got bounds %v, want %v", c.Bounds(), g.Bounds())
+	}
+}
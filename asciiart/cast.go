@@ -0,0 +1,71 @@
+package asciiart
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"strings"
+	"time"
+)
+
+// Header line of an asciicast v2 recording
+type castHeader struct {
+	Version int               `json:"version"`
+	Width   int               `json:"width"`
+	Height  int               `json:"height"`
+	Title   string            `json:"title,omitempty"`
+	Env     map[string]string `json:"env"`
+}
+
+// Record the playback of frames as an asciicast v2 (asciinema) file, with the same output and timing Play would
+// produce. A recording can't repeat forever, so Loops of 0 records a single pass.
+func (p Player) Record(w io.Writer, frames []ResultFrame, title string) error {
+	rendered, err := p.render(frames)
+	if err != nil {
+		return err
+	}
+
+	// Leave a spare line for the cursor after the last row so the terminal never scrolls
+	header := castHeader{Version: 2, Title: title, Env: map[string]string{"TERM": "xterm-256color"}}
+	for _, f := range frames {
+		header.Width = max(header.Width, f.Result.Width())
+		header.Height = max(header.Height, f.Result.Height()+1)
+	}
+
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+	enc.SetEscapeHTML(false)
+
+	err = enc.Encode(header)
+	if err != nil {
+		return err
+	}
+
+	// Players emulate a raw terminal, where a bare line feed doesn't return the cursor to the first column
+	event := func(t time.Duration, data string) error {
+		return enc.Encode([]any{t.Seconds(), "o", strings.ReplaceAll(data, "\n", "\r\n")})
+	}
+
+	err = event(0, ansiHideCursor+ansiClearScreen)
+	if err != nil {
+		return err
+	}
+
+	loops := max(p.Loops, 1)
+	var t time.Duration
+	for range loops {
+		for i, f := range frames {
+			err = event(t, string(rendered[i]))
+			if err != nil {
+				return err
+			}
+			t += p.frameDelay(f.Delay)
+		}
+	}
+
+	err = event(t, ansiReset+ansiShowCursor)
+	if err != nil {
+		return err
+	}
+	return bw.Flush()
+}
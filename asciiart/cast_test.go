@@ -0,0 +1,65 @@
+package asciiart
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPlayerRecord(t *testing.T) {
+	frames := []ResultFrame{
+		{Result: &Result{Art: [][]rune{[]rune("ab"), []rune("<>")}}, Delay: 250 * time.Millisecond},
+		{Result: &Result{Art: [][]rune{[]rune("cd"), []rune("ef")}}},
+	}
+
+	var buf bytes.Buffer
+	err := Player{Loops: 2}.Record(&buf, frames, "test")
+	if err != nil {
+		t.Fatalf("Failed to record: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 7 {
+		t.Fatalf("got %d lines, want a header and 6 events:\n%s", len(lines), buf.String())
+	}
+
+	var header castHeader
+	err = json.Unmarshal([]byte(lines[0]), &header)
+	if err != nil {
+		t.Fatalf("Invalid header: %v", err)
+	}
+	if header.Version != 2 || header.Width != 2 || header.Height != 3 || header.Title != "test" {
+		t.Errorf("got header %+v", header)
+	}
+
+	testData := []struct {
+		time float64
+		data string
+	}{
+		{0, ansiHideCursor + ansiClearScreen},
+		{0, ansiCursorHome + "ab\r\n<>\r\n" + ansiClearToEnd},
+		{0.25, ansiCursorHome + "cd\r\nef\r\n" + ansiClearToEnd},
+		{0.35, ansiCursorHome + "ab\r\n<>\r\n" + ansiClearToEnd},
+		{0.6, ansiCursorHome + "cd\r\nef\r\n" + ansiClearToEnd},
+		{0.7, ansiReset + ansiShowCursor},
+	}
+
+	for i, d := range testData {
+		var event []any
+		err := json.Unmarshal([]byte(lines[i+1]), &event)
+		if err != nil {
+			t.Fatalf("Invalid event %d: %v", i, err)
+		}
+		if len(event) != 3 || event[1] != "o" {
+			t.Fatalf("event %d: got %v", i, event)
+		}
+		if tm := event[0].(float64); tm < d.time-1e-9 || tm > d.time+1e-9 {
+			t.Errorf("event %d: got time %v, want %v", i, tm, d.time)
+		}
+		if event[2] != d.data {
+			t.Errorf("event %d: got %q, want %q", i, event[2], d.data)
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package asciiart
+
+import "fmt"
+
+// Directions a junction connects in, combined as a bit mask to index JunctionGlyphs
+const (
+	armUp = 1 << iota
+	armRight
+	armDown
+	armLeft
+)
+
+// Glyphs for edge cells where horizontal and vertical strokes meet, indexed by the bit mask of the directions
+// (up 1, right 2, down 4, left 8) the cell connects in. Masks without both a horizontal and a vertical direction
+// are never looked up.
+type JunctionGlyphs [16]rune
+
+var (
+	// Corners, tees and crosses from the Unicode box drawing block
+	BoxJunctions = JunctionGlyphs{
+		armRight | armDown:                   '┌',
+		armLeft | armDown:                    '┐',
+		armRight | armUp:                     '└',
+		armLeft | armUp:                      '┘',
+		armUp | armDown | armRight:           '├',
+		armUp | armDown | armLeft:            '┤',
+		armLeft | armRight | armDown:         '┬',
+		armLeft | armRight | armUp:           '┴',
+		armUp | armRight | armDown | armLeft: '┼',
+	}
+
+	// A plus sign for every junction
+	ASCIIJunctions = JunctionGlyphs{
+		armRight | armDown:                   '+',
+		armLeft | armDown:                    '+',
+		armRight | armUp:                     '+',
+		armLeft | armUp:                      '+',
+		armUp | armDown | armRight:           '+',
+		armUp | armDown | armLeft:            '+',
+		armLeft | armRight | armDown:         '+',
+		armLeft | armRight | armUp:           '+',
+		armUp | armRight | armDown | armLeft: '+',
+	}
+)
+
+// Look up a set of junction glyphs by name as accepted by the CLI
+func ParseJunctionGlyphs(name string) (JunctionGlyphs, error) {
+	switch name {
+	case "box":
+		return BoxJunctions, nil
+	case "ascii":
+		return ASCIIJunctions, nil
+	default:
+		return JunctionGlyphs{}, fmt.Errorf("unknown junction glyph set: %q", name)
+	}
+}
+
+// Position of an edge orientation in orientations, or -1 for None and Default
+func orientationIndex(e Edge) int {
+	for i, o := range orientations {
+		if o == e {
+			return i
+		}
+	}
+	return -1
+}
+
+// Whether e runs closer to horizontal than to vertical
+func isHorizontalish(e Edge) bool {
+	i := orientationIndex(e)
+	return i >= 0 && (i < 4 || i > 12)
+}
+
+// Whether e runs closer to vertical than to horizontal
+func isVerticalish(e Edge) bool {
+	i := orientationIndex(e)
+	return i > 4 && i < 12
+}
+
+// Replace the glyphs of edge cells where a horizontal and a vertical stroke meet with connecting corners, tees and
+// crosses, so that outlines read as continuous strokes. A cell connects left or right to a neighboring horizontal
+// edge and up or down to a neighboring vertical one. With 4 neighbors only the directly adjacent cells count; with
+// 8 a stroke also continues into the diagonal cells on either side of its own direction, which joins strokes that
+// step by a cell as they are downscaled.
+// glyphs is the edge glyph grid for edges, as from EdgesToGlyphs, and is not modified.
+func ConnectEdges(edges [][]Edge, glyphs [][]rune, junctions JunctionGlyphs, neighbors int) ([][]rune, error) {
+	if neighbors != 4 && neighbors != 8 {
+		return nil, optionError("neighbors", neighbors, "must be 4 or 8")
+	}
+
+	height := len(edges)
+	if len(glyphs) != height {
+		return nil, fmt.Errorf("%w: heights %d and %d", ErrMismatchedDimensions, height, len(glyphs))
+	}
+
+	at := func(x, y int) Edge {
+		if y < 0 || y >= height || x < 0 || x >= len(edges[y]) {
+			return None
+		}
+		return edges[y][x]
+	}
+
+	// Check the neighbor dx, dy away, along with the ones beside it when diagonals count and the cell itself runs
+	// in that direction
+	connects := func(x, y, dx, dy int, match func(Edge) bool) bool {
+		if match(at(x+dx, y+dy)) {
+			return true
+		}
+		if neighbors == 4 || !match(at(x, y)) {
+			return false
+		}
+		// Step sideways relative to the direction being checked
+		return match(at(x+dx+dy, y+dy+dx)) || match(at(x+dx-dy, y+dy-dx))
+	}
+
+	dst := make([][]rune, height)
+	for y, row := range edges {
+		if len(glyphs[y]) != len(row) {
+			return nil, fmt.Errorf("%w: widths in row %d %d and %d", ErrMismatchedDimensions, y, len(row), len(glyphs[y]))
+		}
+		dst[y] = make([]rune, len(row))
+		copy(dst[y], glyphs[y])
+
+		for x, e := range row {
+			if e == None || e == Default {
+				continue
+			}
+
+			arms := 0
+			if connects(x, y, 0, -1, isVerticalish) {
+				arms |= armUp
+			}
+			if connects(x, y, 1, 0, isHorizontalish) {
+				arms |= armRight
+			}
+			if connects(x, y, 0, 1, isVerticalish) {
+				arms |= armDown
+			}
+			if connects(x, y, -1, 0, isHorizontalish) {
+				arms |= armLeft
+			}
+
+			if arms&(armLeft|armRight) == 0 || arms&(armUp|armDown) == 0 {
+				continue
+			}
+			if junctions[arms] != 0 {
+				dst[y][x] = junctions[arms]
+			}
+		}
+	}
+	return dst, nil
+}
@@ -0,0 +1,130 @@
+package asciiart
+
+import "testing"
+
+// Parse a grid of ASCII edge glyphs back into edges, with any other character as None
+func edgeGrid(rows ...string) [][]Edge {
+	edges := make([][]Edge, len(rows))
+	for y, row := range rows {
+		for _, char := range row {
+			e := None
+			switch char {
+			case '_':
+				e = Horizontal
+			case '|':
+				e = Vertical
+			case '/':
+				e = DiagonalUp
+			case '\\':
+				e = DiagonalDown
+			}
+			edges[y] = append(edges[y], e)
+		}
+	}
+	return edges
+}
+
+func TestConnectEdges(t *testing.T) {
+	testData := []struct {
+		name      string
+		grid      []string
+		neighbors int
+		want      []string
+	}{
+		{
+			name: "box",
+			grid: []string{
+				"/___\\",
+				"| | |",
+				"|___|",
+				"| | |",
+				"\\___/",
+			},
+			neighbors: 4,
+			want: []string{
+				"┌_┬_┐",
+				"| | |",
+				"├_┼_┤",
+				"| | |",
+				"└_┴_┘",
+			},
+		},
+		{
+			name: "diagonals stay",
+			grid: []string{
+				"/  \\",
+				" /\\ ",
+			},
+			neighbors: 8,
+			want: []string{
+				"/  \\",
+				" /\\ ",
+			},
+		},
+		{
+			name: "stepped stroke",
+			grid: []string{
+				"  __",
+				"__  ",
+				"|   ",
+			},
+			neighbors: 4,
+			want: []string{
+				"  __",
+				"┌_  ",
+				"|   ",
+			},
+		},
+		{
+			name: "stepped stroke with diagonals",
+			grid: []string{
+				"  __",
+				"__  ",
+				" |  ",
+			},
+			neighbors: 8,
+			want: []string{
+				"  __",
+				"_┬  ",
+				" |  ",
+			},
+		},
+	}
+
+	for _, d := range testData {
+		t.Run(d.name, func(t *testing.T) {
+			edges := edgeGrid(d.grid...)
+			res, err := ConnectEdges(edges, EdgesToASCII(edges), BoxJunctions, d.neighbors)
+			if err != nil {
+				t.Fatalf("Failed to connect edges: %v", err)
+			}
+			for y, row := range res {
+				if string(row) != d.want[y] {
+					t.Errorf("row %d: got %q, want %q", y, string(row), d.want[y])
+				}
+			}
+		})
+	}
+
+	edges := edgeGrid("__")
+	if _, err := ConnectEdges(edges, EdgesToASCII(edges), BoxJunctions, 6); err == nil {
+		t.Error("expected error for unsupported neighborhood")
+	}
+	if _, err := ConnectEdges(edges, [][]rune{{'_'}}, BoxJunctions, 4); err == nil {
+		t.Error("expected error for mismatched grids")
+	}
+}
+
+func TestParseJunctionGlyphs(t *testing.T) {
+	box, err := ParseJunctionGlyphs("box")
+	if err != nil || box[armUp|armRight|armDown|armLeft] != '┼' {
+		t.Errorf("got %v, %v for box", box, err)
+	}
+	ascii, err := ParseJunctionGlyphs("ascii")
+	if err != nil || ascii[armRight|armDown] != '+' {
+		t.Errorf("got %v, %v for ascii", ascii, err)
+	}
+	if _, err := ParseJunctionGlyphs("rounded"); err == nil {
+		t.Error("expected error for unknown junction glyphs")
+	}
+}
@@ -1,22 +1,50 @@
 package asciiart
 
 import (
-	"fmt"
+	"context"
+	"errors"
 	"image"
-	"log"
+	"log/slog"
+	"time"
 )
 
 type Converter struct {
-	Img        image.Image // image to convert
-	CharSet    []rune      // ascii characters to map image to
-	CharWidth  int         // width of ascii character conversion
-	DOpts      DoGOptions  // options for Difference of Gaussians preprocessing for edge detection
-	SThreshold float32     // minimum threshold (0 to 1) for sobel filter to recognize gradient as edge
-	EThreshold float32     // minimum edge density (0 to 1) in a downscaled block for it to be considered an edge
-	Squash     float32     // factor to compress output height to offset aspect ratio differences between ascii and pixels
-	DoEdges    bool        // whether to apply edge detection
-	DoBase     bool        // whether to apply base ascii luminance mapping
-	DoDoG      bool        // whether to apply Difference of Gaussians preprocessing for edge detection
+	Img        image.Image    // image to convert
+	CharSet    []rune         // ascii characters to map image to
+	CharWidth  int            // width of ascii character conversion
+	DOpts      DoGOptions     // options for Difference of Gaussians preprocessing for edge detection
+	Detector   EdgeDetector   // gradient operator for edge detection
+	SThreshold float32        // minimum threshold (0 to 1) for the edge detector's gradient to be recognized as an edge
+	EThreshold float32        // minimum edge density (0 to 1) in a downscaled block for it to be considered an edge
+	Squash     float32        // factor to compress output height to offset aspect ratio differences between ascii and pixels
+	DoEdges    bool           // whether to apply edge detection
+	DoBase     bool           // whether to apply base ascii luminance mapping
+	DoDoG      bool           // whether to apply Difference of Gaussians preprocessing for edge detection
+	DoColor    bool           // whether to sample the average color of each cell into the result
+	DoCanny    bool           // whether to detect edges with Canny instead of thresholding the gradient magnitude
+	CLow       float32        // low hysteresis threshold (0 to 1) for Canny edge detection
+	CHigh      float32        // high hysteresis threshold (0 to 1) for Canny edge detection
+	Bins       int            // number of edge orientation classes: 4, 8 or 16
+	EdgeGlyphs map[Edge]rune  // glyph drawn for each edge orientation, which must cover every orientation of Bins
+	DoConnect  bool           // whether to replace glyphs where horizontal and vertical edges meet with junctions
+	Junctions  JunctionGlyphs // corner, tee and cross glyphs for connecting edges
+	Neighbors  int            // neighborhood (4 or 8) in which edges connect
+	DoCorners  bool           // whether to detect corners and draw them with the Corner glyph
+	KOpts      CornerOptions  // options for corner detection
+	DoWeighted bool           // whether to weight each pixel's vote for its block's edge by gradient magnitude
+	DoCircular bool           // whether to orient each block by the weighted circular mean of its pixels' angles
+	DoFlow     bool           // whether Difference of Gaussians preprocessing follows the edge tangent flow (FDoG)
+	FOpts      FlowOptions    // options for the edge tangent flow of FDoG preprocessing
+	DoShape    bool           // whether to pick each cell's glyph by shape instead of the luminance and edge passes
+	SOpts      ShapeOptions   // options for glyph shape matching
+	Dither     DitherMethod   // dithering of the downscaled luminance before it is mapped to a charset of 2 to 256 characters
+	Workers    int            // goroutines for the pixel-level stages, 0 for one per CPU
+
+	// Called with the name of each stage of a conversion (downscale, color, shape, luminance, preprocess, edges,
+	// corners, reduce, glyphs, overlay) and the fraction (0 to 1) of it done as it advances, from one goroutine at a time
+	Progress func(stage string, fraction float64)
+
+	Logger *slog.Logger // destination for the timing and dimensions of each stage
 }
 
 func NewConverter(img image.Image, options ...func(*Converter)) *Converter {
@@ -24,13 +52,24 @@ func NewConverter(img image.Image, options ...func(*Converter)) *Converter {
 		Img:        img,
 		CharWidth:  175,
 		CharSet:    []rune(" .:-=+*#%@"),
-		DOpts:      DoGOptions{Sigma1: 4, Sigma2: 10, Epsilon: 0.65, Tau: 0.8, Phi: 25},
+		DOpts:      DoGOptions{Sigma1: 4, Sigma2: 10, Epsilon: 0.65, Tau: 0.8, Phi: 25, Levels: 4},
+		Detector:   Sobel,
 		SThreshold: 0.15,
 		EThreshold: 0.05,
 		Squash:     2.3,
 		DoEdges:    true,
 		DoBase:     true,
 		DoDoG:      true,
+		CLow:       0.05,
+		CHigh:      0.15,
+		Bins:       4,
+		EdgeGlyphs: ASCIIEdgeGlyphs,
+		Junctions:  ASCIIJunctions,
+		Neighbors:  4,
+		FOpts:      FlowOptions{TensorSigma: 2, FlowSigma: 3},
+		KOpts:      CornerOptions{Method: Harris, Sigma: 1.5, K: 0.04, Threshold: 0.1},
+		SOpts:      ShapeOptions{Metric: L2, Cols: 4, Rows: 6},
+		Logger:     discardLogger,
 	}
 
 	for _, opt := range options {
@@ -82,6 +121,60 @@ func WithDPhi(phi float32) func(*Converter) {
 	}
 }
 
+func WithDK(k float32) func(*Converter) {
+	return func(c *Converter) {
+		c.DOpts.K = k
+	}
+}
+
+func WithDForm(form DoGForm) func(*Converter) {
+	return func(c *Converter) {
+		c.DOpts.Form = form
+	}
+}
+
+func WithDThreshold(mode ThresholdMode) func(*Converter) {
+	return func(c *Converter) {
+		c.DOpts.Threshold = mode
+	}
+}
+
+func WithDLevels(levels int) func(*Converter) {
+	return func(c *Converter) {
+		c.DOpts.Levels = levels
+	}
+}
+
+func WithDInvert(invert bool) func(*Converter) {
+	return func(c *Converter) {
+		c.DOpts.Invert = invert
+	}
+}
+
+func WithDoFlow(doFlow bool) func(*Converter) {
+	return func(c *Converter) {
+		c.DoFlow = doFlow
+	}
+}
+
+func WithFTensorSigma(sigma float32) func(*Converter) {
+	return func(c *Converter) {
+		c.FOpts.TensorSigma = sigma
+	}
+}
+
+func WithFFlowSigma(sigma float32) func(*Converter) {
+	return func(c *Converter) {
+		c.FOpts.FlowSigma = sigma
+	}
+}
+
+func WithEdgeDetector(detector EdgeDetector) func(*Converter) {
+	return func(c *Converter) {
+		c.Detector = detector
+	}
+}
+
 func WithSThreshold(threshold float32) func(*Converter) {
 	return func(c *Converter) {
 		c.SThreshold = threshold
@@ -118,63 +211,444 @@ func WithDoDoG(doDoG bool) func(*Converter) {
 	}
 }
 
-func (c *Converter) Convert() ([][]rune, error) {
-	if !c.DoEdges && !c.DoBase {
-		return nil, fmt.Errorf("both edge detection and base ASCII generation are disabled; please enable at least one option")
+func WithDoColor(doColor bool) func(*Converter) {
+	return func(c *Converter) {
+		c.DoColor = doColor
+	}
+}
+
+func WithDoCanny(doCanny bool) func(*Converter) {
+	return func(c *Converter) {
+		c.DoCanny = doCanny
+	}
+}
+
+func WithCLow(threshold float32) func(*Converter) {
+	return func(c *Converter) {
+		c.CLow = threshold
+	}
+}
+
+func WithCHigh(threshold float32) func(*Converter) {
+	return func(c *Converter) {
+		c.CHigh = threshold
+	}
+}
+
+func WithOrientationBins(bins int) func(*Converter) {
+	return func(c *Converter) {
+		c.Bins = bins
+	}
+}
+
+func WithEdgeGlyphs(glyphs map[Edge]rune) func(*Converter) {
+	return func(c *Converter) {
+		c.EdgeGlyphs = glyphs
+	}
+}
+
+func WithDoConnect(doConnect bool) func(*Converter) {
+	return func(c *Converter) {
+		c.DoConnect = doConnect
+	}
+}
+
+func WithJunctions(junctions JunctionGlyphs) func(*Converter) {
+	return func(c *Converter) {
+		c.Junctions = junctions
+	}
+}
+
+func WithNeighbors(neighbors int) func(*Converter) {
+	return func(c *Converter) {
+		c.Neighbors = neighbors
+	}
+}
+
+func WithDoCorners(doCorners bool) func(*Converter) {
+	return func(c *Converter) {
+		c.DoCorners = doCorners
+	}
+}
+
+func WithCornerMethod(method CornerMethod) func(*Converter) {
+	return func(c *Converter) {
+		c.KOpts.Method = method
+	}
+}
+
+func WithCornerSigma(sigma float32) func(*Converter) {
+	return func(c *Converter) {
+		c.KOpts.Sigma = sigma
+	}
+}
+
+func WithCornerK(k float32) func(*Converter) {
+	return func(c *Converter) {
+		c.KOpts.K = k
+	}
+}
+
+func WithCornerThreshold(threshold float32) func(*Converter) {
+	return func(c *Converter) {
+		c.KOpts.Threshold = threshold
+	}
+}
+
+func WithDoWeighted(doWeighted bool) func(*Converter) {
+	return func(c *Converter) {
+		c.DoWeighted = doWeighted
+	}
+}
+
+func WithDoCircular(doCircular bool) func(*Converter) {
+	return func(c *Converter) {
+		c.DoCircular = doCircular
+	}
+}
+
+func WithDoShape(doShape bool) func(*Converter) {
+	return func(c *Converter) {
+		c.DoShape = doShape
+	}
+}
+
+func WithShapeMetric(metric ShapeMetric) func(*Converter) {
+	return func(c *Converter) {
+		c.SOpts.Metric = metric
+	}
+}
+
+func WithShapeGrid(cols, rows int) func(*Converter) {
+	return func(c *Converter) {
+		c.SOpts.Cols = cols
+		c.SOpts.Rows = rows
+	}
+}
+
+func WithDither(method DitherMethod) func(*Converter) {
+	return func(c *Converter) {
+		c.Dither = method
+	}
+}
+
+func WithWorkers(workers int) func(*Converter) {
+	return func(c *Converter) {
+		c.Workers = workers
+	}
+}
+
+func WithProgress(progress func(stage string, fraction float64)) func(*Converter) {
+	return func(c *Converter) {
+		c.Progress = progress
+	}
+}
+
+func WithLogger(logger *slog.Logger) func(*Converter) {
+	return func(c *Converter) {
+		c.Logger = logger
+	}
+}
+
+// Check every option that applies to the enabled stages, returning all that are invalid joined together as
+// *OptionErrors, or nil
+func (c *Converter) Validate() error {
+	var errs []error
+	if c.Img == nil {
+		errs = append(errs, optionError("Img", nil, "must not be nil"))
+	}
+	if !c.DoEdges && !c.DoBase && !c.DoShape {
+		errs = append(errs, optionError("DoEdges", false, "edge detection and base ASCII generation are both disabled"))
+	}
+	if c.CharWidth <= 0 {
+		errs = append(errs, optionError("CharWidth", c.CharWidth, "must be positive"))
+	}
+	if c.Squash <= 0 {
+		errs = append(errs, optionError("Squash", c.Squash, "must be positive"))
+	}
+	if c.Workers < 0 {
+		errs = append(errs, optionError("Workers", c.Workers, "must not be negative"))
+	}
+	if (c.DoBase || c.DoShape) && len(c.CharSet) == 0 {
+		errs = append(errs, optionError("CharSet", string(c.CharSet), "must not be empty"))
+	}
+	if c.Dither < NoDither || c.Dither > Bayer {
+		errs = append(errs, optionError("Dither", c.Dither, "unknown dither method"))
+	} else if c.Dither != NoDither {
+		// Dithering mixes the gray levels of the luminance pass's characters, which stop at the 256 gray values
+		switch {
+		case c.DoShape:
+			errs = append(errs, optionError("Dither", c.Dither, "doesn't apply to shape matching"))
+		case !c.DoBase:
+			errs = append(errs, optionError("Dither", c.Dither, "needs base ASCII generation"))
+		case len(c.CharSet) < 2 || len(c.CharSet) > 256:
+			errs = append(errs, optionError("Dither", c.Dither, "needs a charset of 2 to 256 characters"))
+		}
+	}
+	// Shape matching replaces both of the other passes
+	if c.DoShape {
+		errs = append(errs, validateShapeOptions(c.SOpts))
+		return errors.Join(errs...)
+	}
+	if !c.DoEdges {
+		return errors.Join(errs...)
+	}
+
+	if c.DoDoG {
+		errs = append(errs, validateDoGOptions(c.DOpts))
+		if c.DoFlow {
+			errs = append(errs, validateFlowOptions(c.FOpts))
+		}
+	}
+	if c.DoCanny {
+		if c.CLow < 0 || c.CLow > c.CHigh {
+			errs = append(errs, optionError("CLow", c.CLow, "must be between 0 and CHigh, inclusive"))
+		}
+		if c.CHigh > 1 {
+			errs = append(errs, optionError("CHigh", c.CHigh, "must be at most 1"))
+		}
+	} else if c.SThreshold < 0 || c.SThreshold > 1 {
+		errs = append(errs, optionError("SThreshold", c.SThreshold, "must be between 0 and 1, inclusive"))
+	}
+	if c.EThreshold < 0 || c.EThreshold > 1 {
+		errs = append(errs, optionError("EThreshold", c.EThreshold, "must be between 0 and 1, inclusive"))
+	}
+
+	glyphs := c.EdgeGlyphs
+	if glyphs == nil {
+		glyphs = ASCIIEdgeGlyphs
+	}
+	errs = append(errs, validateEdgeGlyphs(glyphs, c.Bins, c.DoCorners))
+	if c.DoConnect && c.Neighbors != 4 && c.Neighbors != 8 {
+		errs = append(errs, optionError("Neighbors", c.Neighbors, "must be 4 or 8"))
+	}
+	if c.DoCorners {
+		errs = append(errs, validateCornerOptions(c.KOpts))
+	}
+	return errors.Join(errs...)
+}
+
+func (c *Converter) Convert() (*Result, error) {
+	return c.ConvertContext(context.Background())
+}
+
+// Like Convert, stopping between stages and between the rows of the heavier ones once ctx is done, in which case
+// the context's error is returned
+func (c *Converter) ConvertContext(ctx context.Context) (*Result, error) {
+	err := c.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	logger := c.Logger
+	if logger == nil {
+		logger = discardLogger
+	}
+	start := time.Now()
+	bounds := c.Img.Bounds()
+	logger.Info("converting image", "width", bounds.Dx(), "height", bounds.Dy(), "columns", c.CharWidth)
+
+	r := newResult(bounds, c.CharWidth, c.Squash)
+
+	s, err := newStage(ctx, "downscale", c.Progress, logger)
+	if err != nil {
+		return nil, err
+	}
+	g, err := GrayDownscale(c.Img, c.CharWidth, c.Squash)
+	if err != nil {
+		return nil, err
+	}
+	r.Luminance = g
+	s.finish("rows", g.Bounds().Dy())
+
+	if c.DoColor {
+		s, err = newStage(ctx, "color", c.Progress, logger)
+		if err != nil {
+			return nil, err
+		}
+		r.Colors, err = ColorDownscale(c.Img, c.CharWidth, c.Squash)
+		if err != nil {
+			return nil, err
+		}
+		s.finish()
+	}
+
+	if c.DoShape {
+		s, err = newStage(ctx, "shape", c.Progress, logger)
+		if err != nil {
+			return nil, err
+		}
+		atlas, err := NewGlyphAtlas(c.SOpts.Face, c.CharSet, c.SOpts.Cols, c.SOpts.Rows)
+		if err != nil {
+			return nil, err
+		}
+		r.Art, err = matchShapes(c.Img, atlas, c.CharWidth, c.Squash, c.SOpts.Metric, c.Workers, s)
+		if err != nil {
+			return nil, err
+		}
+		s.finish("metric", c.SOpts.Metric.String(), "glyphs", len(c.CharSet))
+
+		logger.Info("converted image", "columns", r.Width(), "rows", r.Height(), "duration", time.Since(start))
+		return r, nil
 	}
 
 	var a [][]rune
 	if c.DoBase {
-		log.Println("Mapping luminance to ascii...")
-		g, err := GrayDownscale(c.Img, c.CharWidth, c.Squash)
+		s, err = newStage(ctx, "luminance", c.Progress, logger)
 		if err != nil {
 			return nil, err
 		}
-
-		a, err = ConvertToASCIIArt(g, c.CharSet)
+		lum := g
+		if c.Dither != NoDither {
+			lum, err = DitherGray(g, len(c.CharSet), c.Dither)
+			if err != nil {
+				return nil, err
+			}
+		}
+		a, err = ConvertToASCIIArt(lum, c.CharSet)
 		if err != nil {
 			return nil, err
 		}
+		s.finish("dither", c.Dither.String())
 	}
 
 	var e [][]rune
 	if c.DoEdges {
-		log.Println("Mapping edges to ascii...")
 
+		glyphs := c.EdgeGlyphs
+		if glyphs == nil {
+			glyphs = ASCIIEdgeGlyphs
+		}
+
+		s, err = newStage(ctx, "preprocess", c.Progress, logger)
+		if err != nil {
+			return nil, err
+		}
 		var d *image.Gray
-		if c.DoDoG {
-			var err error
-			d, err = DoG(c.Img, c.DOpts)
+		method := "grayscale"
+		switch {
+		case c.DoDoG && c.DoFlow:
+			method = "fdog"
+			d, err = fDoG(c.Img, c.DOpts, c.FOpts, c.Workers, s)
 			if err != nil {
 				return nil, err
 			}
-		} else {
-			d = Grayscale(c.Img)
+		case c.DoDoG:
+			method = "dog"
+			d, err = doG(c.Img, c.DOpts, c.Workers, s)
+			if err != nil {
+				return nil, err
+			}
+		default:
+			d = grayscale(c.Img, c.Workers)
+		}
+		s.finish("method", method)
+
+		detector := c.Detector
+		if detector == nil {
+			detector = Sobel
 		}
 
-		m, err := MapEdges(d, c.SThreshold)
+		s, err = newStage(ctx, "edges", c.Progress, logger)
 		if err != nil {
 			return nil, err
+		}
+		// Weighted voting needs each pixel's gradient, which plain edge maps don't keep
+		weighted := c.DoWeighted || c.DoCircular
+		var m [][]Edge
+		var gx, gy []float32
+		if c.DoCanny {
+			m, gx, gy, err = mapEdgesCanny(d, detector, c.CLow, c.CHigh, c.Bins, c.Workers, s)
+		} else {
+			m, gx, gy, err = mapEdges(d, detector, c.SThreshold, c.Bins, c.Workers, s)
+		}
+		if err != nil {
+			return nil, err
+		}
+		var w *WeightedEdges
+		if weighted {
+			w = newWeightedEdges(m, gx, gy)
+		}
+		s.finish("canny", c.DoCanny, "bins", c.Bins)
 
+		if c.DoCorners {
+			s, err = newStage(ctx, "corners", c.Progress, logger)
+			if err != nil {
+				return nil, err
+			}
+			corners, err := mapCorners(d, detector, c.KOpts, c.Workers)
+			if err != nil {
+				return nil, err
+			}
+			err = MarkCorners(m, corners)
+			if err != nil {
+				return nil, err
+			}
+			count := 0
+			for _, row := range corners {
+				for _, corner := range row {
+					if corner {
+						count++
+					}
+				}
+			}
+			s.finish("corners", count)
 		}
 
-		e, err = DownscaleEdges(m, c.CharWidth, c.Squash, c.EThreshold)
+		s, err = newStage(ctx, "reduce", c.Progress, logger)
+		if err != nil {
+			return nil, err
+		}
+		if weighted {
+			r.Edges, r.Density, err = reduceWeightedEdges(w, c.CharWidth, c.Squash, c.EThreshold, c.Bins, c.DoCircular, c.Workers, s)
+		} else {
+			r.Edges, r.Density, err = reduceEdges(m, c.CharWidth, c.Squash, c.EThreshold, c.Workers, s)
+		}
 		if err != nil {
 			return nil, err
 		}
+		s.finish("weighted", weighted, "circular", c.DoCircular)
+
+		s, err = newStage(ctx, "glyphs", c.Progress, logger)
+		if err != nil {
+			return nil, err
+		}
+		e = EdgesToGlyphs(r.Edges, glyphs)
+
+		if c.DoConnect {
+			e, err = ConnectEdges(r.Edges, e, c.Junctions, c.Neighbors)
+			if err != nil {
+				return nil, err
+			}
+		}
+		s.finish()
 	}
 
 	switch {
 	case c.DoEdges && c.DoBase:
+		s, err = newStage(ctx, "overlay", c.Progress, logger)
+		if err != nil {
+			return nil, err
+		}
 		dst, err := OverlayEdges(a, e)
 		if err != nil {
 			return nil, err
 		}
-		return dst, nil
+		r.Art = dst
+		r.Overlaid = make([][]bool, len(r.Edges))
+		for y, row := range r.Edges {
+			r.Overlaid[y] = make([]bool, len(row))
+			for x, edge := range row {
+				r.Overlaid[y][x] = edge != None && edge != Default
+			}
+		}
+		s.finish()
 	case c.DoEdges:
-		return e, nil
+		r.Art = e
 	default:
-		return a, nil
+		r.Art = a
 	}
 
+	logger.Info("converted image", "columns", r.Width(), "rows", r.Height(), "duration", time.Since(start))
+	return r, nil
 }
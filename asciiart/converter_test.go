@@ -1,9 +1,17 @@
 package asciiart
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
 	"image"
+	"image/color"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -31,10 +39,10 @@ func TestConverter(t *testing.T) {
 		t.Log("Converting to ascii with base and edges...")
 		a, err := c.Convert()
 		if err != nil {
-			t.Errorf("Error converting image: %v", err)
+			t.Fatalf("Error converting image: %v", err)
 		}
 
-		for _, row := range a {
+		for _, row := range a.Art {
 			t.Log(string(row))
 		}
 
@@ -42,10 +50,10 @@ func TestConverter(t *testing.T) {
 		t.Log("Converting to ascii without edges...")
 		a, err = c.Convert()
 		if err != nil {
-			t.Errorf("Error converting image: %v", err)
+			t.Fatalf("Error converting image: %v", err)
 		}
 
-		for _, row := range a {
+		for _, row := range a.Art {
 			t.Log(string(row))
 		}
 
@@ -54,12 +62,410 @@ func TestConverter(t *testing.T) {
 		t.Log("Converting to ascii with edges only...")
 		a, err = c.Convert()
 		if err != nil {
-			t.Errorf("Error converting image: %v", err)
+			t.Fatalf("Error converting image: %v", err)
 		}
 
-		for _, row := range a {
+		for _, row := range a.Art {
 			t.Log(string(row))
 		}
 
 	}
 }
+
+func TestConvertResult(t *testing.T) {
+	file, err := os.Open(filepath.Join("..", "testdata", "sample_image_1.png"))
+	if err != nil {
+		t.Fatalf("Failed to open file: %v", err)
+	}
+	defer file.Close()
+
+	img, _, err := image.Decode(file)
+	if err != nil {
+		t.Fatalf("Failed to decode image: %v", err)
+	}
+
+	r, err := NewConverter(img, WithWidth(60), WithDoColor(true)).Convert()
+	if err != nil {
+		t.Fatalf("Error converting image: %v", err)
+	}
+
+	width, height := r.Width(), r.Height()
+	if width != 60 || height == 0 {
+		t.Fatalf("got %d x %d result", width, height)
+	}
+	if r.Luminance.Bounds().Dx() != width || r.Luminance.Bounds().Dy() != height {
+		t.Errorf("luminance is %v, want %d x %d", r.Luminance.Bounds(), width, height)
+	}
+	if r.Colors.Bounds() != r.Luminance.Bounds() {
+		t.Errorf("colors are %v, want %v", r.Colors.Bounds(), r.Luminance.Bounds())
+	}
+	if len(r.Edges) != height || len(r.Density) != height || len(r.Overlaid) != height {
+		t.Fatalf("edge grids have %d, %d and %d rows, want %d", len(r.Edges), len(r.Density), len(r.Overlaid), height)
+	}
+
+	for y := range height {
+		for x := range width {
+			if r.Overlaid[y][x] != (r.Edges[y][x] != None) {
+				t.Errorf("cell %d,%d: overlaid %t with edge %d", x, y, r.Overlaid[y][x], r.Edges[y][x])
+			}
+			if !r.Bounds(x, y).In(img.Bounds()) || r.Bounds(x, y).Empty() {
+				t.Errorf("cell %d,%d: bounds %v outside of %v", x, y, r.Bounds(x, y), img.Bounds())
+			}
+		}
+	}
+}
+
+func TestConvertEdgeGlyphs(t *testing.T) {
+	file, err := os.Open(filepath.Join("..", "testdata", "sample_image_1.png"))
+	if err != nil {
+		t.Fatalf("Failed to open file: %v", err)
+	}
+	defer file.Close()
+
+	img, _, err := image.Decode(file)
+	if err != nil {
+		t.Fatalf("Failed to decode image: %v", err)
+	}
+
+	glyphs := map[Edge]rune{Horizontal: '═', Vertical: '║', DiagonalUp: '╱', DiagonalDown: '╲'}
+	r, err := NewConverter(img, WithWidth(60), WithEdgeGlyphs(glyphs)).Convert()
+	if err != nil {
+		t.Fatalf("Error converting image: %v", err)
+	}
+
+	for y, row := range r.Art {
+		for x, char := range row {
+			if !r.Overlaid[y][x] {
+				continue
+			}
+			if want := glyphs[r.Edges[y][x]]; char != want {
+				t.Errorf("cell %d,%d: got %q for %s, want %q", x, y, char, r.Edges[y][x], want)
+			}
+		}
+	}
+
+	// The four glyphs don't cover the in between orientations of 8 bins
+	_, err = NewConverter(img, WithWidth(60), WithEdgeGlyphs(glyphs), WithOrientationBins(8)).Convert()
+	if err == nil {
+		t.Error("expected error for glyphs missing orientations")
+	}
+}
+
+func TestConvertWorkers(t *testing.T) {
+	file, err := os.Open(filepath.Join("..", "testdata", "sample_image_2.png"))
+	if err != nil {
+		t.Fatalf("Failed to open file: %v", err)
+	}
+	defer file.Close()
+
+	img, _, err := image.Decode(file)
+	if err != nil {
+		t.Fatalf("Failed to decode image: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		options []func(*Converter)
+	}{
+		{"dog", nil},
+		{"grayscale", []func(*Converter){WithDoDoG(false)}},
+		{"flow", []func(*Converter){WithDoFlow(true)}},
+		{"canny", []func(*Converter){WithDoCanny(true), WithOrientationBins(8)}},
+		{"weighted", []func(*Converter){WithDoWeighted(true), WithEdgeDetector(Roberts)}},
+		{"circular", []func(*Converter){WithDoCircular(true), WithDoCanny(true)}},
+		{"corners", []func(*Converter){WithDoCorners(true), WithDoDoG(false)}},
+	}
+
+	for _, tt := range tests {
+		convert := func(workers int) *Result {
+			options := append([]func(*Converter){WithWidth(60), WithWorkers(workers)}, tt.options...)
+			r, err := NewConverter(img, options...).Convert()
+			if err != nil {
+				t.Fatalf("%s: error converting image: %v", tt.name, err)
+			}
+			return r
+		}
+
+		serial := convert(1)
+		parallel := convert(4)
+		if !reflect.DeepEqual(serial.Art, parallel.Art) {
+			t.Errorf("%s: art differs between 1 and 4 workers", tt.name)
+		}
+		if !reflect.DeepEqual(serial.Edges, parallel.Edges) {
+			t.Errorf("%s: edges differ between 1 and 4 workers", tt.name)
+		}
+		if !reflect.DeepEqual(serial.Density, parallel.Density) {
+			t.Errorf("%s: density differs between 1 and 4 workers", tt.name)
+		}
+	}
+}
+
+func TestConvertContext(t *testing.T) {
+	file, err := os.Open(filepath.Join("..", "testdata", "sample_image_0.png"))
+	if err != nil {
+		t.Fatalf("Failed to open file: %v", err)
+	}
+	defer file.Close()
+
+	img, _, err := image.Decode(file)
+	if err != nil {
+		t.Fatalf("Failed to decode image: %v", err)
+	}
+
+	// Every stage starts at 0, only moves forward and finishes at 1
+	var stages []string
+	last := map[string]float64{}
+	progress := func(stage string, fraction float64) {
+		prev, ok := last[stage]
+		switch {
+		case !ok:
+			stages = append(stages, stage)
+			if fraction != 0 {
+				t.Errorf("stage %s started at %v", stage, fraction)
+			}
+		case fraction <= prev:
+			t.Errorf("stage %s went from %v to %v", stage, prev, fraction)
+		}
+		last[stage] = fraction
+	}
+	_, err = NewConverter(img, WithWidth(60), WithDoCorners(true), WithProgress(progress)).ConvertContext(context.Background())
+	if err != nil {
+		t.Fatalf("Error converting image: %v", err)
+	}
+	want := []string{"downscale", "luminance", "preprocess", "edges", "corners", "reduce", "glyphs", "overlay"}
+	if !reflect.DeepEqual(stages, want) {
+		t.Errorf("got stages %v, want %v", stages, want)
+	}
+	for stage, fraction := range last {
+		if fraction != 1 {
+			t.Errorf("stage %s finished at %v", stage, fraction)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = NewConverter(img, WithWidth(60)).ConvertContext(ctx)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("canceled before converting: got error %v, want %v", err, context.Canceled)
+	}
+
+	// Cancel partway through the rows of each instrumented stage
+	for _, stage := range []string{"preprocess", "edges", "reduce"} {
+		ctx, cancel := context.WithCancel(context.Background())
+		var after []string
+		progress := func(s string, fraction float64) {
+			if ctx.Err() != nil {
+				after = append(after, s)
+			}
+			if s == stage && fraction > 0 && fraction < 1 {
+				cancel()
+			}
+		}
+		_, err = NewConverter(img, WithWidth(60), WithProgress(progress)).ConvertContext(ctx)
+		cancel()
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("canceled during %s: got error %v, want %v", stage, err, context.Canceled)
+		}
+		if len(after) > 0 {
+			t.Errorf("canceled during %s: progress reported afterward for %v", stage, after)
+		}
+	}
+}
+
+func TestConvertLogger(t *testing.T) {
+	file, err := os.Open(filepath.Join("..", "testdata", "sample_image_0.png"))
+	if err != nil {
+		t.Fatalf("Failed to open file: %v", err)
+	}
+	defer file.Close()
+
+	img, _, err := image.Decode(file)
+	if err != nil {
+		t.Fatalf("Failed to decode image: %v", err)
+	}
+
+	if NewConverter(img).Logger == nil {
+		t.Error("default logger is nil")
+	}
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+	_, err = NewConverter(img, WithWidth(60), WithLogger(logger)).Convert()
+	if err != nil {
+		t.Fatalf("Error converting image: %v", err)
+	}
+
+	var stages []string
+	var records []map[string]any
+	dec := json.NewDecoder(&buf)
+	for dec.More() {
+		var record map[string]any
+		if err := dec.Decode(&record); err != nil {
+			t.Fatalf("Error decoding log record: %v", err)
+		}
+		records = append(records, record)
+		if record["msg"] == "finished stage" {
+			stages = append(stages, record["stage"].(string))
+			if _, ok := record["duration"].(float64); !ok {
+				t.Errorf("stage %s logged without a duration: %v", record["stage"], record)
+			}
+			// Enums are logged by name, not as bare integers
+			if record["stage"] == "luminance" && record["dither"] != "none" {
+				t.Errorf("got dither %v, want %q", record["dither"], "none")
+			}
+		}
+	}
+
+	if len(records) == 0 || records[0]["msg"] != "converting image" {
+		t.Fatalf("expected the conversion to start with its dimensions: %v", records)
+	}
+	bounds := img.Bounds()
+	if records[0]["width"] != float64(bounds.Dx()) || records[0]["height"] != float64(bounds.Dy()) {
+		t.Errorf("got dimensions %v x %v, want %d x %d", records[0]["width"], records[0]["height"], bounds.Dx(), bounds.Dy())
+	}
+	want := []string{"downscale", "luminance", "preprocess", "edges", "reduce", "glyphs", "overlay"}
+	if !reflect.DeepEqual(stages, want) {
+		t.Errorf("got stages %v, want %v", stages, want)
+	}
+	if last := records[len(records)-1]; last["msg"] != "converted image" || last["columns"] != float64(60) {
+		t.Errorf("expected the conversion to end with its size: %v", last)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	img := image.NewGray(image.Rect(0, 0, 100, 100))
+
+	testData := []struct {
+		name    string
+		options []func(*Converter)
+		want    []string
+	}{
+		{"defaults", nil, nil},
+		{"nothing enabled", []func(*Converter){WithDoEdges(false), WithDoBase(false)}, []string{"DoEdges"}},
+		{"empty charset", []func(*Converter){WithCharset(nil)}, []string{"CharSet"}},
+		{"empty charset without base", []func(*Converter){WithCharset(nil), WithDoBase(false)}, nil},
+		{"zero width and squash", []func(*Converter){WithWidth(0), WithSquash(0)}, []string{"CharWidth", "Squash"}},
+		{"negative workers", []func(*Converter){WithWorkers(-1)}, []string{"Workers"}},
+		{"unknown dither", []func(*Converter){WithDither(DitherMethod(9))}, []string{"Dither"}},
+		{"dither shapes", []func(*Converter){WithDither(Bayer), WithDoShape(true)}, []string{"Dither"}},
+		{"dither without base", []func(*Converter){WithDither(Atkinson), WithDoBase(false)}, []string{"Dither"}},
+		{"dither one character", []func(*Converter){WithDither(FloydSteinberg), WithCharset([]rune("@"))}, []string{"Dither"}},
+		{"dither wide charset", []func(*Converter){WithDither(FloydSteinberg), WithCharset(make([]rune, 257))}, []string{"Dither"}},
+		{"shape grid", []func(*Converter){WithDoShape(true), WithShapeGrid(0, 6)}, []string{"ShapeOptions.Cols"}},
+		{"shape without other passes", []func(*Converter){WithDoShape(true), WithDoEdges(false), WithDoBase(false), WithDSigma1(0)}, nil},
+		{"shape with empty charset", []func(*Converter){WithDoShape(true), WithDoBase(false), WithCharset(nil)}, []string{"CharSet"}},
+		{"dog options", []func(*Converter){WithDSigma1(0), WithDPhi(0)}, []string{"DoGOptions.Sigma1", "DoGOptions.Phi"}},
+		{"dog options without dog", []func(*Converter){WithDSigma1(0), WithDoDoG(false)}, nil},
+		{"dog options without edges", []func(*Converter){WithDSigma1(0), WithDoEdges(false)}, nil},
+		{"flow options", []func(*Converter){WithDoFlow(true), WithFFlowSigma(0)}, []string{"FlowOptions.FlowSigma"}},
+		{"thresholds", []func(*Converter){WithSThreshold(2), WithEThreshold(-1)}, []string{"SThreshold", "EThreshold"}},
+		{"canny thresholds", []func(*Converter){WithDoCanny(true), WithCLow(0.5), WithCHigh(0.2)}, []string{"CLow"}},
+		{"bins", []func(*Converter){WithOrientationBins(5)}, []string{"Bins"}},
+		{"glyphs", []func(*Converter){WithDoCorners(true), WithEdgeGlyphs(map[Edge]rune{Horizontal: '-'})}, []string{"EdgeGlyphs"}},
+		{"neighbors", []func(*Converter){WithDoConnect(true), WithNeighbors(6)}, []string{"Neighbors"}},
+		{"corner options", []func(*Converter){WithDoCorners(true), WithCornerSigma(0)}, []string{"CornerOptions.Sigma"}},
+		{"corner k", []func(*Converter){WithDoCorners(true), WithCornerK(0.3)}, []string{"CornerOptions.K"}},
+	}
+
+	for _, d := range testData {
+		t.Run(d.name, func(t *testing.T) {
+			c := NewConverter(img, d.options...)
+			err := c.Validate()
+			if got := optionFields(err); !reflect.DeepEqual(got, d.want) {
+				t.Errorf("got invalid fields %v, want %v (%v)", got, d.want, err)
+			}
+			if d.want == nil {
+				return
+			}
+			if !errors.Is(err, ErrInvalidOption) {
+				t.Errorf("expected %v to match ErrInvalidOption", err)
+			}
+
+			_, convertErr := c.Convert()
+			if convertErr == nil || convertErr.Error() != err.Error() {
+				t.Errorf("expected Convert to fail validation with %v, got %v", err, convertErr)
+			}
+		})
+	}
+}
+
+func TestConvertShape(t *testing.T) {
+	file, err := os.Open(filepath.Join("..", "testdata", "sample_image_2.png"))
+	if err != nil {
+		t.Fatalf("Failed to open file: %v", err)
+	}
+	defer file.Close()
+
+	img, _, err := image.Decode(file)
+	if err != nil {
+		t.Fatalf("Failed to decode image: %v", err)
+	}
+
+	charset := " .:-=+*#%@|/\\_"
+	for _, metric := range []ShapeMetric{L2, SSIM} {
+		r, err := NewConverter(img, WithWidth(60), WithCharset([]rune(charset)), WithDoShape(true), WithShapeMetric(metric), WithDoColor(true)).Convert()
+		if err != nil {
+			t.Fatalf("metric %s: error converting image: %v", metric, err)
+		}
+
+		if r.Width() != r.Luminance.Bounds().Dx() || r.Height() != r.Luminance.Bounds().Dy() {
+			t.Errorf("metric %s: got %d x %d art for %v luminance", metric, r.Width(), r.Height(), r.Luminance.Bounds())
+		}
+		if r.Colors.Bounds() != r.Luminance.Bounds() {
+			t.Errorf("metric %s: got %v colors for %v luminance", metric, r.Colors.Bounds(), r.Luminance.Bounds())
+		}
+		if r.Edges != nil || r.Overlaid != nil {
+			t.Errorf("metric %s: shape matching shouldn't run the edge pass", metric)
+		}
+		counts := map[rune]int{}
+		for _, row := range r.Art {
+			for _, char := range row {
+				if !strings.ContainsRune(charset, char) {
+					t.Fatalf("metric %s: %q is not in the charset", metric, char)
+				}
+				counts[char]++
+			}
+		}
+		// The image's range of tones comes out as a range of glyphs, not a wall of the inkiest one
+		if len(counts) < 3*len(charset)/4 || counts['@'] > r.Width()*r.Height()/5 {
+			t.Errorf("metric %s: got glyph counts %v", metric, counts)
+		}
+	}
+
+	// Cells of 7 x 14 pixels holding black, a vertical bar, an underscore, white and mid gray
+	cells := image.NewGray(image.Rect(0, 0, 5*7, 14))
+	for y := range 14 {
+		for x := range 7 {
+			if x == 3 {
+				cells.SetGray(7+x, y, color.Gray{Y: 255})
+			}
+			if y >= 11 {
+				cells.SetGray(14+x, y, color.Gray{Y: 255})
+			}
+			cells.SetGray(21+x, y, color.Gray{Y: 255})
+			cells.SetGray(28+x, y, color.Gray{Y: 128})
+		}
+	}
+	testData := []struct {
+		metric ShapeMetric
+		want   string
+	}{
+		{L2, " |_@*"},
+		{SSIM, "?|_??"},
+	}
+	for _, d := range testData {
+		r, err := NewConverter(cells, WithWidth(5), WithSquash(2), WithCharset([]rune(charset)), WithDoShape(true), WithShapeMetric(d.metric)).Convert()
+		if err != nil {
+			t.Fatalf("metric %s: error converting image: %v", d.metric, err)
+		}
+		if len(r.Art) != 1 {
+			t.Fatalf("metric %s: got %d rows, want 1", d.metric, len(r.Art))
+		}
+		// Flat cells have no structure for SSIM to go by, so only the bar and underscore are checked
+		for x, want := range d.want {
+			if want != '?' && r.Art[0][x] != want {
+				t.Errorf("metric %s: cell %d: got %q, want %q", d.metric, x, r.Art[0][x], want)
+			}
+		}
+	}
+}
@@ -0,0 +1,183 @@
+package asciiart
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"math"
+)
+
+type CornerMethod int
+
+const (
+	Harris    CornerMethod = iota // det(M) - k trace(M)², favoring strong corners
+	ShiTomasi                     // smaller eigenvalue of M, more stable to rank
+)
+
+type CornerOptions struct {
+	Method    CornerMethod // response function of the structure tensor M
+	Sigma     float32      // standard deviation of the Gaussian window the gradients are summed over
+	K         float32      // sensitivity of the Harris response, usually 0.04 to 0.06
+	Threshold float32      // minimum response (0 to 1) as a fraction of the strongest in the image
+}
+
+// Look up a corner response by name as accepted by the CLI
+func ParseCornerMethod(name string) (CornerMethod, error) {
+	switch name {
+	case "", "harris":
+		return Harris, nil
+	case "shi-tomasi":
+		return ShiTomasi, nil
+	default:
+		return Harris, fmt.Errorf("unknown corner method: %q", name)
+	}
+}
+
+func validateCornerOptions(opts CornerOptions) error {
+	var errs []error
+	if opts.Method != Harris && opts.Method != ShiTomasi {
+		errs = append(errs, optionError("CornerOptions.Method", opts.Method, "unknown corner method"))
+	}
+	if opts.Sigma <= 0 {
+		errs = append(errs, optionError("CornerOptions.Sigma", opts.Sigma, "must be positive"))
+	}
+	// From k = 1/4 on, det(M) - k trace(M)² is never positive, so no corner could pass the threshold
+	if opts.Method == Harris && (opts.K <= 0 || opts.K >= 0.25) {
+		errs = append(errs, optionError("CornerOptions.K", opts.K, "must be between 0 and 0.25, exclusive"))
+	}
+	if opts.Threshold < 0 || opts.Threshold > 1 {
+		errs = append(errs, optionError("CornerOptions.Threshold", opts.Threshold, "must be between 0 and 1, inclusive"))
+	}
+	return errors.Join(errs...)
+}
+
+// Separable Gaussian blur of a row-major float image, clamping at the borders, over workers goroutines
+func gaussianBlur(src []float32, width, height int, sigma float32, workers int) []float32 {
+	kernel := gaussianKernel(sigma)
+	r := len(kernel) / 2
+
+	tmp := make([]float32, len(src))
+	parallelRows(height, workers, func(y0, y1 int) {
+		for y := y0; y < y1; y++ {
+			for x := range width {
+				var v float32
+				for k, w := range kernel {
+					sx := min(max(x+k-r, 0), width-1)
+					v += w * src[y*width+sx]
+				}
+				tmp[y*width+x] = v
+			}
+		}
+	})
+
+	dst := make([]float32, len(src))
+	parallelRows(height, workers, func(y0, y1 int) {
+		for y := y0; y < y1; y++ {
+			for x := range width {
+				var v float32
+				for k, w := range kernel {
+					sy := min(max(y+k-r, 0), height-1)
+					v += w * tmp[sy*width+x]
+				}
+				dst[y*width+x] = v
+			}
+		}
+	})
+	return dst
+}
+
+// Find the corners of an image from the structure tensor of detector's gradients: pixels whose Harris or
+// Shi-Tomasi response is the largest of their 3x3 neighborhood and at least the threshold fraction of the
+// strongest response. The result is indexed [y][x] like the edge maps.
+func MapCorners(img *image.Gray, detector EdgeDetector, opts CornerOptions) ([][]bool, error) {
+	return mapCorners(img, detector, opts, 0)
+}
+
+func mapCorners(img *image.Gray, detector EdgeDetector, opts CornerOptions, workers int) ([][]bool, error) {
+	err := validateCornerOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	width := img.Bounds().Dx()
+	height := img.Bounds().Dy()
+	gx, gy := detectGradients(detector, img, workers)
+
+	xx := make([]float32, width*height)
+	yy := make([]float32, width*height)
+	xy := make([]float32, width*height)
+	for i := range gx {
+		xx[i] = gx[i] * gx[i]
+		yy[i] = gy[i] * gy[i]
+		xy[i] = gx[i] * gy[i]
+	}
+	xx = gaussianBlur(xx, width, height, opts.Sigma, workers)
+	yy = gaussianBlur(yy, width, height, opts.Sigma, workers)
+	xy = gaussianBlur(xy, width, height, opts.Sigma, workers)
+
+	response := make([]float32, width*height)
+	var strongest float32
+	for i := range response {
+		trace := xx[i] + yy[i]
+		switch opts.Method {
+		case Harris:
+			det := xx[i]*yy[i] - xy[i]*xy[i]
+			response[i] = det - opts.K*trace*trace
+		case ShiTomasi:
+			half := (xx[i] - yy[i]) / 2
+			response[i] = trace/2 - float32(math.Sqrt(float64(half*half+xy[i]*xy[i])))
+		}
+		strongest = max(strongest, response[i])
+	}
+
+	corners := make([][]bool, height)
+	for y := range height {
+		corners[y] = make([]bool, width)
+	}
+	if strongest <= 0 {
+		return corners, nil
+	}
+
+	threshold := opts.Threshold * strongest
+	for y := 1; y < height-1; y++ {
+		for x := 1; x < width-1; x++ {
+			i := y*width + x
+			v := response[i]
+			if v <= 0 || v < threshold {
+				continue
+			}
+
+			// Strict toward earlier neighbors so plateaus keep a single pixel
+			peak := true
+			for ky := -1; ky <= 1 && peak; ky++ {
+				for kx := -1; kx <= 1; kx++ {
+					n := response[i+ky*width+kx]
+					if n > v || n == v && (ky < 0 || ky == 0 && kx < 0) {
+						peak = false
+						break
+					}
+				}
+			}
+			corners[y][x] = peak
+		}
+	}
+	return corners, nil
+}
+
+// Set the pixels of an edge map that are corners to Corner
+func MarkCorners(edges [][]Edge, corners [][]bool) error {
+	if len(edges) != len(corners) {
+		return fmt.Errorf("%w: heights %d and %d", ErrMismatchedDimensions, len(edges), len(corners))
+	}
+	for y, row := range corners {
+		if len(row) != len(edges[y]) {
+			return fmt.Errorf("%w: widths in row %d %d and %d", ErrMismatchedDimensions, y, len(edges[y]), len(row))
+		}
+		for x, corner := range row {
+			if corner {
+				edges[y][x] = Corner
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package asciiart
+
+import (
+	"image"
+	"testing"
+)
+
+// Positions of the corners found in img
+func cornerPoints(t *testing.T, img *image.Gray, opts CornerOptions) []image.Point {
+	t.Helper()
+	corners, err := MapCorners(img, Sobel, opts)
+	if err != nil {
+		t.Fatalf("Failed to map corners: %v", err)
+	}
+
+	var points []image.Point
+	for y, row := range corners {
+		for x, corner := range row {
+			if corner {
+				points = append(points, image.Pt(x, y))
+			}
+		}
+	}
+	return points
+}
+
+func TestMapCorners(t *testing.T) {
+	// White square on black, with corners at (10, 10), (29, 10), (10, 29) and (29, 29)
+	square := grayImage(40, 40, func(x, y int) uint8 {
+		if x >= 10 && x < 30 && y >= 10 && y < 30 {
+			return 255
+		}
+		return 0
+	})
+	want := []image.Point{{10, 10}, {29, 10}, {10, 29}, {29, 29}}
+
+	methods := map[string]CornerMethod{"harris": Harris, "shi-tomasi": ShiTomasi}
+	for name, method := range methods {
+		t.Run(name, func(t *testing.T) {
+			points := cornerPoints(t, square, CornerOptions{Method: method, Sigma: 1.5, K: 0.04, Threshold: 0.1})
+			if len(points) != len(want) {
+				t.Fatalf("got %d corners %v, want %d", len(points), points, len(want))
+			}
+			for _, w := range want {
+				found := false
+				for _, p := range points {
+					d := p.Sub(w)
+					if d.X >= -2 && d.X <= 2 && d.Y >= -2 && d.Y <= 2 {
+						found = true
+					}
+				}
+				if !found {
+					t.Errorf("no corner near %v in %v", w, points)
+				}
+			}
+		})
+	}
+
+	// A straight step has no corners
+	step := grayImage(40, 40, func(x, y int) uint8 {
+		if x >= 20 {
+			return 255
+		}
+		return 0
+	})
+	if points := cornerPoints(t, step, CornerOptions{Method: Harris, Sigma: 1.5, K: 0.04, Threshold: 0.1}); len(points) != 0 {
+		t.Errorf("got corners %v on a straight edge", points)
+	}
+
+	if _, err := MapCorners(step, Sobel, CornerOptions{Method: Harris, Sigma: 0, K: 0.04, Threshold: 0.1}); err == nil {
+		t.Error("expected error for non-positive sigma")
+	}
+	if _, err := MapCorners(step, Sobel, CornerOptions{Method: Harris, Sigma: 1.5, K: 0.04, Threshold: 2}); err == nil {
+		t.Error("expected error for threshold above 1")
+	}
+	for _, k := range []float32{0, -0.04, 0.25} {
+		if _, err := MapCorners(step, Sobel, CornerOptions{Method: Harris, Sigma: 1.5, K: k, Threshold: 0.1}); err == nil {
+			t.Errorf("expected error for Harris k %v", k)
+		}
+	}
+	// Shi-Tomasi doesn't use k
+	if _, err := MapCorners(step, Sobel, CornerOptions{Method: ShiTomasi, Sigma: 1.5, Threshold: 0.1}); err != nil {
+		t.Errorf("Shi-Tomasi without k: %v", err)
+	}
+}
+
+func TestReduceEdgesCorner(t *testing.T) {
+	edges := make([][]Edge, 8)
+	for y := range edges {
+		edges[y] = make([]Edge, 8)
+		for x := range edges[y] {
+			edges[y][x] = Horizontal
+		}
+	}
+	edges[2][5] = Corner
+	edges[5][2], edges[5][3], edges[6][2] = Corner, Corner, Corner
+
+	// A lone corner pixel doesn't outweigh the block's lines, but corners denser than the threshold do
+	reduced, density, err := ReduceEdges(edges, 2, 1, 0.1)
+	if err != nil {
+		t.Fatalf("Failed to reduce edges: %v", err)
+	}
+	want := [][]Edge{{Horizontal, Horizontal}, {Corner, Horizontal}}
+	wantDensity := [][]float32{{1, 15.0 / 16}, {3.0 / 16, 1}}
+	for y, row := range want {
+		for x, e := range row {
+			if reduced[y][x] != e {
+				t.Errorf("block %d,%d: got %s, want %s", x, y, reduced[y][x], e)
+			}
+			if density[y][x] != wantDensity[y][x] {
+				t.Errorf("block %d,%d: got density %f, want %f", x, y, density[y][x], wantDensity[y][x])
+			}
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package asciiart
+
+import (
+	"fmt"
+	"image"
+)
+
+// EdgeDetector computes the intensity gradients of a grayscale image
+type EdgeDetector interface {
+	// Horizontal and vertical change at every pixel as row-major slices, each normalized to [-1, 1] so that a full
+	// black to white step gives a magnitude of 1 along its axis. Pixels the operator can't be applied to are 0.
+	Gradients(img *image.Gray) (gx, gy []float32)
+}
+
+// Gradient operator given by a pair of square, odd sized convolution kernels
+type kernelDetector struct {
+	gx, gy [][]int
+}
+
+var (
+	// 3x3 Sobel operator, smoothing across the direction of change with weights 1, 2, 1
+	Sobel EdgeDetector = kernelDetector{
+		gx: [][]int{
+			{-1, 0, 1},
+			{-2, 0, 2},
+			{-1, 0, 1},
+		},
+		gy: [][]int{
+			{-1, -2, -1},
+			{0, 0, 0},
+			{1, 2, 1},
+		},
+	}
+
+	// 3x3 Scharr operator, with weights tuned for better rotational symmetry than Sobel
+	Scharr EdgeDetector = kernelDetector{
+		gx: [][]int{
+			{-3, 0, 3},
+			{-10, 0, 10},
+			{-3, 0, 3},
+		},
+		gy: [][]int{
+			{-3, -10, -3},
+			{0, 0, 0},
+			{3, 10, 3},
+		},
+	}
+
+	// 3x3 Prewitt operator, smoothing across the direction of change with equal weights
+	Prewitt EdgeDetector = kernelDetector{
+		gx: [][]int{
+			{-1, 0, 1},
+			{-1, 0, 1},
+			{-1, 0, 1},
+		},
+		gy: [][]int{
+			{-1, -1, -1},
+			{0, 0, 0},
+			{1, 1, 1},
+		},
+	}
+
+	// 2x2 Roberts cross operator, the smallest and most noise sensitive of the four
+	Roberts EdgeDetector = robertsDetector{}
+)
+
+// Look up an edge detector by name as accepted by the CLI
+func ParseEdgeDetector(name string) (EdgeDetector, error) {
+	switch name {
+	case "", "sobel":
+		return Sobel, nil
+	case "scharr":
+		return Scharr, nil
+	case "prewitt":
+		return Prewitt, nil
+	case "roberts":
+		return Roberts, nil
+	default:
+		return nil, fmt.Errorf("unknown edge operator: %q", name)
+	}
+}
+
+// Detectors that can split their work over rows of the image implement this alongside Gradients
+type rowParallelDetector interface {
+	gradients(img *image.Gray, workers int) (gx, gy []float32)
+}
+
+// Gradients of img from detector, spread over workers goroutines when the detector supports it
+func detectGradients(detector EdgeDetector, img *image.Gray, workers int) (gx, gy []float32) {
+	if p, ok := detector.(rowParallelDetector); ok {
+		return p.gradients(img, workers)
+	}
+	return detector.Gradients(img)
+}
+
+func (k kernelDetector) Gradients(img *image.Gray) (gx, gy []float32) {
+	return k.gradients(img, 0)
+}
+
+func (k kernelDetector) gradients(img *image.Gray, workers int) (gx, gy []float32) {
+	// The largest response along an axis is a step from black to white across the kernel's positive weights
+	norm := 0
+	for _, row := range k.gx {
+		for _, w := range row {
+			norm += max(w, 0)
+		}
+	}
+	scale := 1 / float32(255*norm)
+
+	width := img.Bounds().Dx()
+	height := img.Bounds().Dy()
+	r := len(k.gx) / 2
+
+	gx = make([]float32, width*height)
+	gy = make([]float32, width*height)
+	parallelRows(height, workers, func(y0, y1 int) {
+		for y := max(y0, r); y < min(y1, height-r); y++ {
+			for x := r; x < width-r; x++ {
+				sumX := 0
+				sumY := 0
+				for ky := -r; ky <= r; ky++ {
+					row := img.Pix[(y+ky)*img.Stride+x-r:]
+					for kx := -r; kx <= r; kx++ {
+						pixel := int(row[kx+r])
+						sumX += pixel * k.gx[ky+r][kx+r]
+						sumY += pixel * k.gy[ky+r][kx+r]
+					}
+				}
+				gx[y*width+x] = float32(sumX) * scale
+				gy[y*width+x] = float32(sumY) * scale
+			}
+		}
+	})
+	return gx, gy
+}
+
+type robertsDetector struct{}
+
+func (d robertsDetector) Gradients(img *image.Gray) (gx, gy []float32) {
+	return d.gradients(img, 0)
+}
+
+func (robertsDetector) gradients(img *image.Gray, workers int) (gx, gy []float32) {
+	width := img.Bounds().Dx()
+	height := img.Bounds().Dy()
+
+	gx = make([]float32, width*height)
+	gy = make([]float32, width*height)
+	parallelRows(height-1, workers, func(y0, y1 int) {
+		for y := y0; y < y1; y++ {
+			row := img.Pix[y*img.Stride:]
+			next := img.Pix[(y+1)*img.Stride:]
+			for x := 0; x < width-1; x++ {
+				p00 := float32(row[x])
+				p10 := float32(row[x+1])
+				p01 := float32(next[x])
+				p11 := float32(next[x+1])
+
+				// The cross kernels measure change along the diagonals; rotate them back onto the x and y axes
+				d1 := p00 - p11
+				d2 := p10 - p01
+				gx[y*width+x] = (d2 - d1) / (2 * 255)
+				gy[y*width+x] = -(d1 + d2) / (2 * 255)
+			}
+		}
+	})
+	return gx, gy
+}
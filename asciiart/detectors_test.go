@@ -0,0 +1,95 @@
+package asciiart
+
+import (
+	"math"
+	"testing"
+)
+
+func TestDetectorNormalization(t *testing.T) {
+	detectors := map[string]EdgeDetector{
+		"sobel":   Sobel,
+		"scharr":  Scharr,
+		"prewitt": Prewitt,
+		"roberts": Roberts,
+	}
+
+	vertical := grayImage(10, 10, func(x, y int) uint8 {
+		if x < 5 {
+			return 0
+		}
+		return 255
+	})
+	horizontal := grayImage(10, 10, func(x, y int) uint8 {
+		if y < 5 {
+			return 0
+		}
+		return 255
+	})
+
+	// Largest absolute value over the whole gradient slice
+	peak := func(g []float32) float64 {
+		m := 0.0
+		for _, v := range g {
+			m = max(m, math.Abs(float64(v)))
+		}
+		return m
+	}
+
+	for name, d := range detectors {
+		t.Run(name, func(t *testing.T) {
+			gx, gy := d.Gradients(vertical)
+			if p := peak(gx); math.Abs(p-1) > 1e-6 {
+				t.Errorf("vertical step: got peak horizontal gradient %v, want 1", p)
+			}
+			if p := peak(gy); p != 0 {
+				t.Errorf("vertical step: got peak vertical gradient %v, want 0", p)
+			}
+
+			gx, gy = d.Gradients(horizontal)
+			if p := peak(gy); math.Abs(p-1) > 1e-6 {
+				t.Errorf("horizontal step: got peak vertical gradient %v, want 1", p)
+			}
+			if p := peak(gx); p != 0 {
+				t.Errorf("horizontal step: got peak horizontal gradient %v, want 0", p)
+			}
+		})
+	}
+}
+
+func TestDetectorOrientation(t *testing.T) {
+	// Bright below the anti-diagonal, so the boundary rises from bottom left to top right
+	diagonal := grayImage(20, 20, func(x, y int) uint8 {
+		if x+y < 20 {
+			return 0
+		}
+		return 255
+	})
+
+	for _, name := range []string{"sobel", "scharr", "prewitt", "roberts"} {
+		t.Run(name, func(t *testing.T) {
+			d, err := ParseEdgeDetector(name)
+			if err != nil {
+				t.Fatalf("Failed to parse edge detector: %v", err)
+			}
+
+			edges, err := MapEdgesWith(diagonal, d, 0.2, 4)
+			if err != nil {
+				t.Fatalf("Failed to map edges: %v", err)
+			}
+
+			counts := make(map[Edge]int)
+			for _, row := range edges {
+				for _, e := range row {
+					counts[e]++
+				}
+			}
+			if counts[DiagonalUp] == 0 || counts[Horizontal]+counts[Vertical]+counts[DiagonalDown] > counts[DiagonalUp] {
+				t.Errorf("got edge counts %v, want mostly diagonal up", counts)
+			}
+		})
+	}
+
+	if _, err := ParseEdgeDetector("laplace"); err == nil {
+		t.Errorf("expected error parsing an unknown edge operator")
+	}
+}
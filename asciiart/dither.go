@@ -0,0 +1,139 @@
+package asciiart
+
+import (
+	"fmt"
+	"image"
+	"math"
+)
+
+type DitherMethod int
+
+const (
+	NoDither          DitherMethod = iota // straight quantization, banding on smooth gradients
+	FloydSteinberg                        // error diffusion onto the 4 following neighbors
+	Atkinson                              // error diffusion of 3/4 of the error onto 6 neighbors, keeping more contrast
+	JarvisJudiceNinke                     // error diffusion onto 12 neighbors, smoother but blurrier
+	Bayer                                 // ordered dithering with a 4x4 Bayer threshold matrix, without diffusion
+)
+
+var ditherNames = map[DitherMethod]string{
+	NoDither:          "none",
+	FloydSteinberg:    "floyd-steinberg",
+	Atkinson:          "atkinson",
+	JarvisJudiceNinke: "jjn",
+	Bayer:             "bayer",
+}
+
+func (m DitherMethod) String() string {
+	if name, ok := ditherNames[m]; ok {
+		return name
+	}
+	return fmt.Sprintf("DitherMethod(%d)", int(m))
+}
+
+// Look up a dithering method by name as accepted by the CLI
+func ParseDitherMethod(name string) (DitherMethod, error) {
+	if name == "" {
+		return NoDither, nil
+	}
+	for m, n := range ditherNames {
+		if n == name {
+			return m, nil
+		}
+	}
+	return NoDither, fmt.Errorf("unknown dither method: %q", name)
+}
+
+// Share of the quantization error given to the pixel dx, dy away
+type diffusion struct {
+	dx, dy int
+	weight float32
+}
+
+var diffusionKernels = map[DitherMethod][]diffusion{
+	FloydSteinberg: {
+		{1, 0, 7.0 / 16},
+		{-1, 1, 3.0 / 16}, {0, 1, 5.0 / 16}, {1, 1, 1.0 / 16},
+	},
+	Atkinson: {
+		{1, 0, 1.0 / 8}, {2, 0, 1.0 / 8},
+		{-1, 1, 1.0 / 8}, {0, 1, 1.0 / 8}, {1, 1, 1.0 / 8},
+		{0, 2, 1.0 / 8},
+	},
+	JarvisJudiceNinke: {
+		{1, 0, 7.0 / 48}, {2, 0, 5.0 / 48},
+		{-2, 1, 3.0 / 48}, {-1, 1, 5.0 / 48}, {0, 1, 7.0 / 48}, {1, 1, 5.0 / 48}, {2, 1, 3.0 / 48},
+		{-2, 2, 1.0 / 48}, {-1, 2, 3.0 / 48}, {0, 2, 5.0 / 48}, {1, 2, 3.0 / 48}, {2, 2, 1.0 / 48},
+	},
+}
+
+var bayer4 = [4][4]float32{
+	{0, 8, 2, 10},
+	{12, 4, 14, 6},
+	{3, 11, 1, 9},
+	{15, 7, 13, 5},
+}
+
+// Quantize a grayscale image to levels evenly spaced gray values, dithering so that smooth gradients are drawn
+// with a mix of neighboring levels instead of bands. The levels are chosen so that ConvertToASCIIArt maps each one
+// to its own character of a charset with levels characters. NoDither returns a copy of img.
+func DitherGray(img *image.Gray, levels int, method DitherMethod) (*image.Gray, error) {
+	if levels < 2 || levels > 256 {
+		return nil, optionError("levels", levels, "must be between 2 and 256, inclusive")
+	}
+	_, diffuse := diffusionKernels[method]
+	if method != NoDither && method != Bayer && !diffuse {
+		return nil, optionError("Dither", method, "unknown dither method")
+	}
+
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	dst := image.NewGray(bounds)
+	if method == NoDither {
+		for y := range height {
+			copy(dst.Pix[y*dst.Stride:y*dst.Stride+width], img.Pix[y*img.Stride:])
+		}
+		return dst, nil
+	}
+
+	// Quantize to the nearest level, writing the smallest gray value ConvertToASCIIArt maps to that level's index
+	step := 255 / float32(levels-1)
+	quantize := func(x, y int, v float32) float32 {
+		k := min(max(int(math.Round(float64(v/step))), 0), levels-1)
+		dst.Pix[y*dst.Stride+x] = uint8((k*255 + levels - 2) / (levels - 1))
+		return float32(k) * step
+	}
+
+	if method == Bayer {
+		for y := range height {
+			for x := range width {
+				// Offset each pixel by up to half a step either way, by its position in the tiled matrix
+				offset := ((bayer4[y%4][x%4]+0.5)/16 - 0.5) * step
+				quantize(x, y, float32(img.Pix[y*img.Stride+x])+offset)
+			}
+		}
+		return dst, nil
+	}
+
+	buf := make([]float32, width*height)
+	for y := range height {
+		for x := range width {
+			buf[y*width+x] = float32(img.Pix[y*img.Stride+x])
+		}
+	}
+	kernel := diffusionKernels[method]
+	for y := range height {
+		for x := range width {
+			old := buf[y*width+x]
+			e := old - quantize(x, y, old)
+			for _, d := range kernel {
+				nx, ny := x+d.dx, y+d.dy
+				if nx < 0 || nx >= width || ny >= height {
+					continue
+				}
+				buf[ny*width+nx] += e * d.weight
+			}
+		}
+	}
+	return dst, nil
+}
@@ -0,0 +1,124 @@
+package asciiart
+
+import (
+	"errors"
+	"image"
+	"math"
+	"testing"
+)
+
+func TestParseDitherMethod(t *testing.T) {
+	testData := []struct {
+		name    string
+		want    DitherMethod
+		wantErr bool
+	}{
+		{"", NoDither, false},
+		{"none", NoDither, false},
+		{"floyd-steinberg", FloydSteinberg, false},
+		{"atkinson", Atkinson, false},
+		{"jjn", JarvisJudiceNinke, false},
+		{"bayer", Bayer, false},
+		{"ordered", NoDither, true},
+	}
+
+	for _, d := range testData {
+		got, err := ParseDitherMethod(d.name)
+		if (err != nil) != d.wantErr {
+			t.Errorf("%q: got error %v, want error %t", d.name, err, d.wantErr)
+		}
+		if got != d.want {
+			t.Errorf("%q: got %v, want %v", d.name, got, d.want)
+		}
+		if !d.wantErr && d.name != "" && got.String() != d.name {
+			t.Errorf("%q: got name %q", d.name, got.String())
+		}
+	}
+}
+
+func TestDitherGray(t *testing.T) {
+	charset := []rune(" .:-=+*#%@")
+	levels := len(charset)
+
+	// A flat gray a third of the way between the 5th and 6th characters
+	const width, height = 48, 48
+	step := 255.0 / float64(levels-1)
+	value := 4*step + step/3
+	img := image.NewGray(image.Rect(0, 0, width, height))
+	for i := range img.Pix {
+		img.Pix[i] = uint8(math.Round(value))
+	}
+
+	for _, method := range []DitherMethod{FloydSteinberg, Atkinson, JarvisJudiceNinke, Bayer} {
+		dst, err := DitherGray(img, levels, method)
+		if err != nil {
+			t.Fatalf("method %s: %v", method, err)
+		}
+		if dst.Bounds() != img.Bounds() {
+			t.Errorf("method %s: got bounds %v, want %v", method, dst.Bounds(), img.Bounds())
+		}
+
+		art, err := ConvertToASCIIArt(dst, charset)
+		if err != nil {
+			t.Fatalf("method %s: %v", method, err)
+		}
+
+		// Only the two neighboring characters are used, in proportion to where the gray falls between them
+		counts := map[rune]int{}
+		for _, row := range art {
+			for _, char := range row {
+				counts[char]++
+			}
+		}
+		if len(counts) != 2 || counts['='] == 0 || counts['+'] == 0 {
+			t.Errorf("method %s: expected a mix of '=' and '+', got %v", method, counts)
+			continue
+		}
+		upper := float64(counts['+']) / (width * height)
+		if math.Abs(upper-1.0/3) > 0.05 {
+			t.Errorf("method %s: got %.2f of cells on the upper character, want about 0.33", method, upper)
+		}
+	}
+
+	plain, err := DitherGray(img, levels, NoDither)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, v := range plain.Pix {
+		if v != img.Pix[i] {
+			t.Fatalf("NoDither changed pixel %d from %d to %d", i, img.Pix[i], v)
+		}
+	}
+
+	if _, err := DitherGray(img, 1, FloydSteinberg); !errors.Is(err, ErrInvalidOption) {
+		t.Errorf("one level: got error %v, want %v", err, ErrInvalidOption)
+	}
+	if _, err := DitherGray(img, levels, DitherMethod(9)); !errors.Is(err, ErrInvalidOption) {
+		t.Errorf("unknown method: got error %v, want %v", err, ErrInvalidOption)
+	}
+}
+
+func TestDitherGrayLevels(t *testing.T) {
+	// Every gray value a level is written as maps back to that level's character
+	img := image.NewGray(image.Rect(0, 0, 256, 1))
+	for x := range 256 {
+		img.Pix[x] = uint8(x)
+	}
+
+	for _, levels := range []int{2, 3, 7, 10, 70, 256} {
+		dst, err := DitherGray(img, levels, FloydSteinberg)
+		if err != nil {
+			t.Fatalf("%d levels: %v", levels, err)
+		}
+		seen := map[uint8]bool{}
+		for _, v := range dst.Pix {
+			seen[v] = true
+		}
+		for v := range seen {
+			k := int(v) * (levels - 1) / 255
+			if want := uint8((k*255 + levels - 2) / (levels - 1)); v != want {
+				t.Errorf("%d levels: got gray %d, which is not the value of level %d (%d)", levels, v, k, want)
+			}
+		}
+	}
+}
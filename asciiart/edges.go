@@ -1,9 +1,9 @@
 package asciiart
 
 import (
+	"errors"
 	"fmt"
 	"image"
-	"log"
 	"math"
 )
 
@@ -16,185 +16,594 @@ const (
 	Vertical          // "|"
 	DiagonalUp        // "/"
 	DiagonalDown      // "\"
+
+	// Intermediate orientations of the 8 and 16 bin modes, named by the edge's slope from horizontal
+	FlatUp           // 11.25°
+	ShallowUp        // 22.5°
+	LowDiagonalUp    // 33.75°
+	HighDiagonalUp   // 56.25°
+	SteepUp          // 67.5°
+	NearVerticalUp   // 78.75°
+	NearVerticalDown // 101.25°
+	SteepDown        // 112.5°
+	HighDiagonalDown // 123.75°
+	LowDiagonalDown  // 146.25°
+	ShallowDown      // 157.5°
+	FlatDown         // 168.75°
+
+	Corner // Point where edges of different directions meet
 )
 
+// Number of Edge values, for tallying them in arrays
+const numEdges = Corner + 1
+
+var edgeNames = map[Edge]string{
+	Default:          "default",
+	None:             "none",
+	Horizontal:       "horizontal",
+	Vertical:         "vertical",
+	DiagonalUp:       "diagonal-up",
+	DiagonalDown:     "diagonal-down",
+	FlatUp:           "flat-up",
+	ShallowUp:        "shallow-up",
+	LowDiagonalUp:    "low-diagonal-up",
+	HighDiagonalUp:   "high-diagonal-up",
+	SteepUp:          "steep-up",
+	NearVerticalUp:   "near-vertical-up",
+	NearVerticalDown: "near-vertical-down",
+	SteepDown:        "steep-down",
+	HighDiagonalDown: "high-diagonal-down",
+	LowDiagonalDown:  "low-diagonal-down",
+	ShallowDown:      "shallow-down",
+	FlatDown:         "flat-down",
+	Corner:           "corner",
+}
+
+func (e Edge) String() string {
+	if name, ok := edgeNames[e]; ok {
+		return name
+	}
+	return fmt.Sprintf("Edge(%d)", int(e))
+}
+
+// Look up an edge by its name, e.g. "diagonal-up"
+func ParseEdge(name string) (Edge, error) {
+	for e, n := range edgeNames {
+		if n == name {
+			return e, nil
+		}
+	}
+	return None, fmt.Errorf("unknown edge: %q", name)
+}
+
+// Edge orientations in steps of π/16, counterclockwise from horizontal
+var orientations = [16]Edge{
+	Horizontal, FlatUp, ShallowUp, LowDiagonalUp,
+	DiagonalUp, HighDiagonalUp, SteepUp, NearVerticalUp,
+	Vertical, NearVerticalDown, SteepDown, HighDiagonalDown,
+	DiagonalDown, LowDiagonalDown, ShallowDown, FlatDown,
+}
+
+// Check that bins is a supported number of orientation classes
+func validateOrientationBins(bins int) error {
+	switch bins {
+	case 4, 8, 16:
+		return nil
+	default:
+		return optionError("Bins", bins, "must be 4, 8 or 16")
+	}
+}
+
+// Edge orientations produced when quantizing into bins classes
+func binOrientations(bins int) []Edge {
+	dst := make([]Edge, bins)
+	for i := range bins {
+		dst[i] = orientations[i*len(orientations)/bins]
+	}
+	return dst
+}
+
+// Angle of x, y folded into [0, π], since edges have no direction
+func foldedAngle(x, y float32) float64 {
+	// math.Atan2 outputs -π to π radians
+	angle := math.Atan2(float64(y), float64(x))
+	if angle < 0 {
+		angle += math.Pi
+	}
+	return angle
+}
+
 // Compute angle of X Y gradients and map to discrete edges if magnitude above threshold
 func xyToEdge(x, y, threshold float32) Edge {
+	return xyToOrientation(x, y, threshold, 4)
+}
+
+// Like xyToEdge, quantizing the angle into one of bins (4, 8 or 16) orientations
+func xyToOrientation(x, y, threshold float32, bins int) Edge {
 	magnitude := math.Hypot(float64(y), float64(x))
 	if magnitude < float64(threshold) || magnitude == 0 {
 		return None
 	}
 
-	// math.Atan2 outputs -π to π radians
-	angle := math.Atan2(float64(y), float64(x))
+	angle := foldedAngle(x, y)
 
-	// Normalize angle to the range [0, π]
-	angle = math.Mod(angle, 2*math.Pi)
-	if angle < 0 {
-		angle += math.Pi
+	// Round to the nearest bin, wrapping angles close to π back onto horizontal
+	bin := int(math.Floor(angle/(math.Pi/float64(bins))+0.5)) % bins
+	return orientations[bin*len(orientations)/bins]
+}
+
+// Largest gradient magnitude an EdgeDetector can produce, which thresholds are given as a fraction of
+const maxGradientMagnitude = math.Sqrt2
+
+// Edge map with Default borders and every interior pixel set by classify, called from workers goroutines
+func newEdgeMap(width, height, workers int, s *stage, classify func(x, y int) Edge) ([][]Edge, error) {
+	edges := make([][]Edge, height)
+	for y := range height {
+		edges[y] = make([]Edge, width)
 	}
 
-	// Map the angle to the appropriate edge type
-	switch {
-	case angle >= 0 && angle < math.Pi/8 || angle >= 15*math.Pi/8 && angle <= 2*math.Pi:
-		return Horizontal
-	case angle >= math.Pi/8 && angle < 3*math.Pi/8:
-		return DiagonalUp
-	case angle >= 3*math.Pi/8 && angle < 5*math.Pi/8:
-		return Vertical
-	case angle >= 5*math.Pi/8 && angle < 7*math.Pi/8:
-		return DiagonalDown
-	default:
-		return Horizontal // Handles angles close to multiples of π
+	err := s.rows(height, workers, func(y int) {
+		if y == 0 || y == height-1 {
+			return
+		}
+		for x := 1; x < width-1; x++ {
+			edges[y][x] = classify(x, y)
+		}
+	})
+	if err != nil {
+		return nil, err
 	}
+	return edges, nil
 }
 
 // Map an image to a 2d slice of Edge types
 func MapEdges(img *image.Gray, sobelThreshold float32) ([][]Edge, error) {
-	if sobelThreshold < 0 || sobelThreshold > 1 {
-		return nil, fmt.Errorf("sobel filter threshold must be between 0 and 1, inclusive")
+	return MapEdgesWith(img, Sobel, sobelThreshold, 4)
+}
+
+// Edge map that also keeps the strength and exact orientation of each edge pixel's gradient
+type WeightedEdges struct {
+	Edges     [][]Edge
+	Magnitude [][]float32 // gradient magnitude of edge pixels as a fraction (0 to 1) of the largest possible, else 0
+	Angle     [][]float32 // orientation of edge pixels in radians (0 to π), counterclockwise from horizontal
+}
+
+// Attach the magnitude and orientation of the gradients gx, gy to the edge pixels of edges
+func newWeightedEdges(edges [][]Edge, gx, gy []float32) *WeightedEdges {
+	w := &WeightedEdges{
+		Edges:     edges,
+		Magnitude: make([][]float32, len(edges)),
+		Angle:     make([][]float32, len(edges)),
 	}
-	log.Println("Mapping edges...")
-	threshold := sobelThreshold * float32(math.Hypot(255*4, 255*4))
+	for y, row := range edges {
+		w.Magnitude[y] = make([]float32, len(row))
+		w.Angle[y] = make([]float32, len(row))
+		for x, e := range row {
+			if e == None || e == Default {
+				continue
+			}
+			i := y*len(row) + x
+			w.Magnitude[y][x] = float32(math.Hypot(float64(gx[i]), float64(gy[i])) / maxGradientMagnitude)
+			w.Angle[y][x] = float32(foldedAngle(gy[i], gx[i]))
+		}
+	}
+	return w
+}
+
+// Map an image to a 2d slice of Edge types using the gradients of detector, classifying pixels whose gradient
+// magnitude is at least threshold (0 to 1) of the largest possible into one of bins (4, 8 or 16) orientations
+func MapEdgesWith(img *image.Gray, detector EdgeDetector, threshold float32, bins int) ([][]Edge, error) {
+	edges, _, _, err := mapEdges(img, detector, threshold, bins, 0, nil)
+	return edges, err
+}
+
+// Like MapEdgesWith, keeping the magnitude and orientation of every edge pixel for ReduceWeightedEdges
+func MapWeightedEdges(img *image.Gray, detector EdgeDetector, threshold float32, bins int) (*WeightedEdges, error) {
+	edges, gx, gy, err := mapEdges(img, detector, threshold, bins, 0, nil)
+	if err != nil {
+		return nil, err
+	}
+	return newWeightedEdges(edges, gx, gy), nil
+}
 
-	Gx := [3][3]int{
-		{-1, 0, 1},
-		{-2, 0, 2},
-		{-1, 0, 1},
+func mapEdges(img *image.Gray, detector EdgeDetector, threshold float32, bins, workers int, s *stage) ([][]Edge, []float32, []float32, error) {
+	if threshold < 0 || threshold > 1 {
+		return nil, nil, nil, optionError("threshold", threshold, "must be between 0 and 1, inclusive")
 	}
-	Gy := [3][3]int{
-		{-1, -2, -1},
-		{0, 0, 0},
-		{1, 2, 1},
+	if err := validateOrientationBins(bins); err != nil {
+		return nil, nil, nil, err
 	}
+	magnitude := threshold * maxGradientMagnitude
 
 	width := img.Bounds().Dx()
 	height := img.Bounds().Dy()
+	gx, gy := detectGradients(detector, img, workers)
 
-	edges := make([][]Edge, height)
-	for y := range height {
-		edges[y] = make([]Edge, width)
+	edges, err := newEdgeMap(width, height, workers, s, func(x, y int) Edge {
+		// High horizontal change = vertical edge
+		// High vertical change = horizontal edge
+		// Note position of x, y
+		i := y*width + x
+		return xyToOrientation(gy[i], gx[i], magnitude, bins)
+	})
+	if err != nil {
+		return nil, nil, nil, err
 	}
+	return edges, gx, gy, nil
+}
 
-	for y := 1; y < height-1; y++ {
-		for x := 1; x < width-1; x++ {
-			// High horizontal change = vertical edge
-			// High vertical change = horizontal edge
-			sumX := 0
-			sumY := 0
-			for ky := -1; ky <= 1; ky++ {
-				for kx := -1; kx <= 1; kx++ {
-					pixel := int(img.GrayAt(x+kx, y+ky).Y)
-					sumX += pixel * Gx[ky+1][kx+1]
-					sumY += pixel * Gy[ky+1][kx+1]
+// Map an image to a 2d slice of Edge types with the Canny edge detector: the gradients of detector are thinned to
+// one pixel wide ridges by non-maximum suppression along the gradient direction, then hysteresis keeps ridge pixels
+// above the high threshold along with any above the low threshold that connect to them. Thresholds are fractions
+// (0 to 1) of the largest possible gradient magnitude, and the kept pixels are classified into bins orientations,
+// like the threshold and bins of MapEdgesWith.
+func MapEdgesCanny(img *image.Gray, detector EdgeDetector, low, high float32, bins int) ([][]Edge, error) {
+	edges, _, _, err := mapEdgesCanny(img, detector, low, high, bins, 0, nil)
+	return edges, err
+}
+
+// Like MapEdgesCanny, keeping the magnitude and orientation of every edge pixel for ReduceWeightedEdges
+func MapWeightedEdgesCanny(img *image.Gray, detector EdgeDetector, low, high float32, bins int) (*WeightedEdges, error) {
+	edges, gx, gy, err := mapEdgesCanny(img, detector, low, high, bins, 0, nil)
+	if err != nil {
+		return nil, err
+	}
+	return newWeightedEdges(edges, gx, gy), nil
+}
+
+func mapEdgesCanny(img *image.Gray, detector EdgeDetector, low, high float32, bins, workers int, s *stage) ([][]Edge, []float32, []float32, error) {
+	if low < 0 || high > 1 || low > high {
+		return nil, nil, nil, optionError("canny thresholds", fmt.Sprintf("%v, %v", low, high), "must satisfy 0 <= low <= high <= 1")
+	}
+	if err := validateOrientationBins(bins); err != nil {
+		return nil, nil, nil, err
+	}
+	lowThreshold := low * maxGradientMagnitude
+	highThreshold := high * maxGradientMagnitude
+
+	width := img.Bounds().Dx()
+	height := img.Bounds().Dy()
+	gx, gy := detectGradients(detector, img, workers)
+
+	magnitude := make([]float32, width*height)
+	parallelRows(height, workers, func(y0, y1 int) {
+		for i := y0 * width; i < y1*width; i++ {
+			magnitude[i] = float32(math.Hypot(float64(gx[i]), float64(gy[i])))
+		}
+	})
+
+	// Non-maximum suppression: keep pixels at least as strong as both neighbors along the gradient, quantized to
+	// the nearest of four directions
+	ridge := make([]bool, width*height)
+	parallelRows(height, workers, func(y0, y1 int) {
+		for y := max(y0, 1); y < min(y1, height-1); y++ {
+			for x := 1; x < width-1; x++ {
+				i := y*width + x
+				m := magnitude[i]
+				if m == 0 || m < lowThreshold {
+					continue
+				}
+
+				angle := math.Atan2(float64(gy[i]), float64(gx[i]))
+				if angle < 0 {
+					angle += math.Pi
+				}
+
+				var dx, dy int
+				switch {
+				case angle < math.Pi/8 || angle >= 7*math.Pi/8:
+					dx, dy = 1, 0
+				case angle < 3*math.Pi/8:
+					dx, dy = 1, 1
+				case angle < 5*math.Pi/8:
+					dx, dy = 0, 1
+				default:
+					dx, dy = -1, 1
+				}
+
+				// Strict on one side so plateaus two pixels wide keep exactly one of them
+				if m > magnitude[i-dy*width-dx] && m >= magnitude[i+dy*width+dx] {
+					ridge[i] = true
 				}
 			}
+		}
+	})
 
-			// Note position of x, y
-			edges[y][x] = xyToEdge(float32(sumY), float32(sumX), threshold)
+	if err := s.err(); err != nil {
+		return nil, nil, nil, err
+	}
+
+	// Hysteresis: flood from strong ridge pixels through 8-connected ridge pixels above the low threshold
+	kept := make([]bool, width*height)
+	var stack []int
+	for i, r := range ridge {
+		if r && magnitude[i] >= highThreshold {
+			kept[i] = true
+			stack = append(stack, i)
 		}
 	}
-	return edges, nil
+	for len(stack) > 0 {
+		i := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		for ky := -1; ky <= 1; ky++ {
+			for kx := -1; kx <= 1; kx++ {
+				j := i + ky*width + kx
+				if ridge[j] && !kept[j] {
+					kept[j] = true
+					stack = append(stack, j)
+				}
+			}
+		}
+	}
+
+	edges, err := newEdgeMap(width, height, workers, s, func(x, y int) Edge {
+		i := y*width + x
+		if !kept[i] {
+			return None
+		}
+		return xyToOrientation(gy[i], gx[i], 0, bins)
+	})
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return edges, gx, gy, nil
 }
 
-func DownscaleEdges(edges [][]Edge, newWidth int, hWeight, threshold float32) ([][]rune, error) {
+func validateReduceOptions(newWidth int, hWeight, threshold float32) error {
+	var errs []error
 	if newWidth <= 0 {
-		return nil, fmt.Errorf("non-positive newWidth: %d", newWidth)
+		errs = append(errs, optionError("newWidth", newWidth, "must be positive"))
 	}
-
 	if hWeight <= 0 {
-		return nil, fmt.Errorf("non-positive hWeight: %2f", hWeight)
+		errs = append(errs, optionError("hWeight", hWeight, "must be positive"))
 	}
-
 	if threshold < 0 || threshold > 1 {
-		return nil, fmt.Errorf("threshold needs to be between 0 and 1: %2f", threshold)
+		errs = append(errs, optionError("threshold", threshold, "must be between 0 and 1, inclusive"))
 	}
+	return errors.Join(errs...)
+}
 
-	log.Println("Downscaling edges...")
-	height := len(edges)
-	width := len(edges[0])
-
+// Downscale a width x height edge map to newWidth columns of blocks hWeight times as tall as they are wide,
+// setting each block's edge and density by vote over the pixels it covers. Rows of blocks are split over workers
+// goroutines, so vote must be safe to call concurrently.
+func reduceBlocks(width, height, newWidth int, hWeight float32, workers int, s *stage, vote func(pixels []image.Point) (Edge, float32)) ([][]Edge, [][]float32, error) {
 	xScale := float64(width) / float64(newWidth)
 	yScale := xScale * float64(hWeight)
 	newHeight := int(math.Floor(float64(height) / yScale))
 
-	dst := make([][]rune, newHeight)
+	dst := make([][]Edge, newHeight)
+	density := make([][]float32, newHeight)
 	for y := range newHeight {
-		dst[y] = make([]rune, newWidth)
+		dst[y] = make([]Edge, newWidth)
+		density[y] = make([]float32, newWidth)
 	}
 
-	getSubmatrixEdge := func(x int, y int) (Edge, error) {
-		edgeCounts := make(map[Edge]int)
-		total := 0
-		// Analyze the current submatrix of size scale x scale
-		for subY := 0; float64(subY) < yScale; subY++ {
-			for subX := 0; float64(subX) < xScale; subX++ {
-				i := int(math.Floor(float64(y)*yScale)) + subY
-				j := int(math.Floor(float64(x)*xScale)) + subX
+	// Keep the error of each row so the first one is reported no matter how the rows were split
+	errs := make([]error, newHeight)
+	err := s.rows(newHeight, workers, func(y int) {
+		var pixels []image.Point
+		for x := range newWidth {
+			// Analyze the current submatrix of size scale x scale
+			pixels = pixels[:0]
+			for subY := 0; float64(subY) < yScale; subY++ {
+				for subX := 0; float64(subX) < xScale; subX++ {
+					i := int(math.Floor(float64(y)*yScale)) + subY
+					j := int(math.Floor(float64(x)*xScale)) + subX
 
-				if i >= len(edges) {
-					return None, fmt.Errorf("y out of range: %d from %d", i, len(edges))
-				}
-				if j >= len(edges[0]) {
-					return None, fmt.Errorf("x out of range: %d from %d", j, len(edges[0]))
+					if i >= height {
+						errs[y] = fmt.Errorf("y out of range: %d from %d", i, height)
+						return
+					}
+					if j >= width {
+						errs[y] = fmt.Errorf("x out of range: %d from %d", j, width)
+						return
+					}
+					pixels = append(pixels, image.Pt(j, i))
 				}
+			}
+			dst[y][x], density[y][x] = vote(pixels)
+		}
+	})
+	if err != nil {
+		return nil, nil, err
+	}
 
-				edge := edges[i][j]
-				edgeCounts[edge]++
-				total++
+	for _, err := range errs {
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	return dst, density, nil
+}
+
+// Reduce an edge map to the dominant edge of each downscaled block, along with the fraction of the block's
+// (non-border) pixels that share it. Blocks whose density does not exceed threshold are None, and blocks whose
+// Corner pixels alone exceed it are Corner whatever their lines. Ties go to the edge declared first.
+func ReduceEdges(edges [][]Edge, newWidth int, hWeight, threshold float32) ([][]Edge, [][]float32, error) {
+	return reduceEdges(edges, newWidth, hWeight, threshold, 0, nil)
+}
+
+func reduceEdges(edges [][]Edge, newWidth int, hWeight, threshold float32, workers int, s *stage) ([][]Edge, [][]float32, error) {
+	err := validateReduceOptions(newWidth, hWeight, threshold)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(edges) == 0 || len(edges[0]) == 0 {
+		return nil, nil, fmt.Errorf("%w: edge map has no pixels", ErrEmptyGrid)
+	}
+
+	return reduceBlocks(len(edges[0]), len(edges), newWidth, hWeight, workers, s, func(pixels []image.Point) (Edge, float32) {
+		var counts [numEdges]int
+		for _, p := range pixels {
+			e := edges[p.Y][p.X]
+			if e < 0 || e >= numEdges {
+				e = None
 			}
+			counts[e]++
+		}
+
+		counted := len(pixels) - counts[Default]
+		if counted == 0 {
+			return None, 0
+		}
+
+		// Corners are single pixels, so they win the block once they alone pass the density threshold, outweighing
+		// the lines running into them
+		if d := float32(counts[Corner]) / float32(counted); d > threshold {
+			return Corner, d
 		}
 
 		maxCount := 0
 		maxEdge := None
-		for edge, count := range edgeCounts {
-			if edge != None && edge != Default && count >= maxCount {
-				maxCount = count
-				maxEdge = edge
+		for e := Horizontal; e < Corner; e++ {
+			if counts[e] > maxCount {
+				maxCount = counts[e]
+				maxEdge = e
 			}
 		}
 
-		if float32(maxCount)/float32(total-edgeCounts[Default]) > threshold {
-			return maxEdge, nil
+		d := float32(maxCount) / float32(counted)
+		if d > threshold {
+			return maxEdge, d
 		}
-		return None, nil
+		return None, d
+	})
+}
+
+// Reduce a weighted edge map like ReduceEdges, but with each pixel's vote, and the density compared against
+// threshold, weighted by its gradient magnitude, so that a few strong edges outweigh many faint ones. With circular,
+// the block's orientation is instead the magnitude weighted circular mean of its pixels' angles, quantized into
+// bins orientations, and its density is the length of that mean, which also falls as the angles disagree.
+func ReduceWeightedEdges(w *WeightedEdges, newWidth int, hWeight, threshold float32, bins int, circular bool) ([][]Edge, [][]float32, error) {
+	return reduceWeightedEdges(w, newWidth, hWeight, threshold, bins, circular, 0, nil)
+}
+
+func reduceWeightedEdges(w *WeightedEdges, newWidth int, hWeight, threshold float32, bins int, circular bool, workers int, s *stage) ([][]Edge, [][]float32, error) {
+	err := validateReduceOptions(newWidth, hWeight, threshold)
+	if err != nil {
+		return nil, nil, err
+	}
+	err = validateOrientationBins(bins)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(w.Edges) == 0 || len(w.Edges[0]) == 0 {
+		return nil, nil, fmt.Errorf("%w: edge map has no pixels", ErrEmptyGrid)
 	}
 
-	for y := range newHeight {
-		for x := range newWidth {
-			e, err := getSubmatrixEdge(x, y)
-			if err != nil {
-				return nil, err
+	return reduceBlocks(len(w.Edges[0]), len(w.Edges), newWidth, hWeight, workers, s, func(pixels []image.Point) (Edge, float32) {
+		var weights [numEdges]float32
+		var defaults, corners int
+		var sumCos, sumSin float64
+		for _, p := range pixels {
+			e := w.Edges[p.Y][p.X]
+			switch {
+			case e == Default:
+				defaults++
+			case e == Corner:
+				corners++
+			case e > None && e < Corner:
+				m := w.Magnitude[p.Y][p.X]
+				weights[e] += m
+				if circular {
+					// Orientations repeat every π, so average the doubled angles
+					a := 2 * float64(w.Angle[p.Y][p.X])
+					sumCos += float64(m) * math.Cos(a)
+					sumSin += float64(m) * math.Sin(a)
+				}
+			}
+		}
+
+		counted := len(pixels) - defaults
+		if counted == 0 {
+			return None, 0
+		}
+
+		if d := float32(corners) / float32(counted); d > threshold {
+			return Corner, d
+		}
+
+		if circular {
+			d := float32(math.Hypot(sumCos, sumSin) / float64(counted))
+			if d <= threshold {
+				return None, d
+			}
+			angle := math.Atan2(sumSin, sumCos) / 2
+			return xyToOrientation(float32(math.Cos(angle)), float32(math.Sin(angle)), 0, bins), d
+		}
+
+		var maxWeight float32
+		maxEdge := None
+		for e := Horizontal; e < Corner; e++ {
+			if weights[e] > maxWeight {
+				maxWeight = weights[e]
+				maxEdge = e
 			}
-			switch e {
-			case Horizontal:
-				dst[y][x] = '_'
-			case DiagonalUp:
-				dst[y][x] = '/'
-			case Vertical:
-				dst[y][x] = '|'
-			case DiagonalDown:
-				dst[y][x] = '\\'
-			default:
-				dst[y][x] = ' '
+		}
+
+		d := maxWeight / float32(counted)
+		if d > threshold {
+			return maxEdge, d
+		}
+		return None, d
+	})
+}
+
+// Map each edge direction to its ascii character
+func EdgesToASCII(edges [][]Edge) [][]rune {
+	return EdgesToGlyphs(edges, ASCIIEdgeGlyphs)
+}
+
+// Map each edge direction to its glyph in glyphs, leaving None, Default and directions missing from the table blank
+func EdgesToGlyphs(edges [][]Edge, glyphs map[Edge]rune) [][]rune {
+	dst := make([][]rune, len(edges))
+	for y, row := range edges {
+		dst[y] = make([]rune, len(row))
+		for x, e := range row {
+			glyph, ok := glyphs[e]
+			if !ok || e == None || e == Default {
+				glyph = ' '
 			}
+			dst[y][x] = glyph
 		}
 	}
+	return dst
+}
 
-	return dst, nil
+func DownscaleEdges(edges [][]Edge, newWidth int, hWeight, threshold float32) ([][]rune, error) {
+	e, _, err := ReduceEdges(edges, newWidth, hWeight, threshold)
+	if err != nil {
+		return nil, err
+	}
+
+	return EdgesToASCII(e), nil
 }
 
+// Width of the first row of a grid, or 0 if it has none
+func gridWidth(grid [][]rune) int {
+	if len(grid) == 0 {
+		return 0
+	}
+	return len(grid[0])
+}
+
+// Overlay edge glyphs onto base, keeping the base character wherever the edge glyph is blank
 func OverlayEdges(base, edges [][]rune) ([][]rune, error) {
-	log.Println("Overlaying edges...")
+	if len(base) == 0 || len(base[0]) == 0 || len(edges) == 0 || len(edges[0]) == 0 {
+		return nil, fmt.Errorf("%w: %d x %d base and %d x %d edges", ErrEmptyGrid, gridWidth(base), len(base), gridWidth(edges), len(edges))
+	}
 	width := len(base[0])
 	height := len(base)
 
 	if width != len(edges[0]) || height != len(edges) {
-		return nil, fmt.Errorf("mismatched dimensions: %d x %d and %d x %d", width, height, len(edges), len(edges[0]))
+		return nil, fmt.Errorf("%w: %d x %d base and %d x %d edges", ErrMismatchedDimensions, width, height, len(edges[0]), len(edges))
 	}
 
 	dst := make([][]rune, height)
 	for y := range height {
+		if len(base[y]) != width || len(edges[y]) != width {
+			return nil, fmt.Errorf("%w: widths in row %d %d and %d", ErrMismatchedDimensions, y, len(base[y]), len(edges[y]))
+		}
 		dst[y] = make([]rune, width)
 		for x := range width {
 			if edges[y][x] == ' ' {
@@ -1,9 +1,11 @@
 package asciiart
 
 import (
+	"errors"
 	"fmt"
 	"image"
 	_ "image/jpeg"
+	"math"
 	"os"
 	"path/filepath"
 	"testing"
@@ -37,6 +39,43 @@ func TestXYToEdge(t *testing.T) {
 	}
 }
 
+func TestXYToOrientation(t *testing.T) {
+	testData := []struct {
+		degrees float64
+		bins    int
+		want    Edge
+	}{
+		{0, 8, Horizontal},
+		{20, 8, ShallowUp},
+		{45, 8, DiagonalUp},
+		{70, 8, SteepUp},
+		{-70, 8, SteepDown},
+		{200, 8, ShallowUp},
+		{170, 8, Horizontal},
+		{10, 16, FlatUp},
+		{35, 16, LowDiagonalUp},
+		{55, 16, HighDiagonalUp},
+		{80, 16, NearVerticalUp},
+		{100, 16, NearVerticalDown},
+		{125, 16, HighDiagonalDown},
+		{145, 16, LowDiagonalDown},
+		{170, 16, FlatDown},
+		{175, 16, Horizontal},
+		{80, 4, Vertical},
+	}
+
+	for _, d := range testData {
+		testname := fmt.Sprintf("%.0f,%d", d.degrees, d.bins)
+		t.Run(testname, func(t *testing.T) {
+			rad := d.degrees * math.Pi / 180
+			res := xyToOrientation(float32(math.Cos(rad)), float32(math.Sin(rad)), 0.5, d.bins)
+			if res != d.want {
+				t.Errorf("got %d, want %d", res, d.want)
+			}
+		})
+	}
+}
+
 func TestMapEdges(t *testing.T) {
 	filePath := filepath.Join("..", "testdata", "downscaled_gray_0.png")
 	file, err := os.Open(filePath)
@@ -129,3 +168,248 @@ func TestEdgesIntegration(t *testing.T) {
 
 	}
 }
+
+// Grayscale image filled by f
+func grayImage(width, height int, f func(x, y int) uint8) *image.Gray {
+	img := image.NewGray(image.Rect(0, 0, width, height))
+	for y := range height {
+		for x := range width {
+			img.Pix[y*img.Stride+x] = f(x, y)
+		}
+	}
+	return img
+}
+
+func TestMapEdgesCannyThinning(t *testing.T) {
+	img := grayImage(20, 20, func(x, y int) uint8 {
+		if x < 10 {
+			return 0
+		}
+		return 255
+	})
+
+	count := func(edges [][]Edge, y int) int {
+		n := 0
+		for _, e := range edges[y] {
+			if e != None && e != Default {
+				n++
+			}
+		}
+		return n
+	}
+
+	sobelEdges, err := MapEdges(img, 0.15)
+	if err != nil {
+		t.Fatalf("Failed to map edges: %v", err)
+	}
+	cannyEdges, err := MapEdgesCanny(img, Sobel, 0.05, 0.15, 4)
+	if err != nil {
+		t.Fatalf("Failed to map edges: %v", err)
+	}
+
+	for y := 1; y < 19; y++ {
+		if n := count(sobelEdges, y); n != 2 {
+			t.Errorf("row %d: got %d sobel edge pixels, want 2", y, n)
+		}
+		if n := count(cannyEdges, y); n != 1 {
+			t.Errorf("row %d: got %d canny edge pixels, want 1", y, n)
+		}
+		if cannyEdges[y][9] != Vertical {
+			t.Errorf("row %d: got %d, want vertical edge", y, cannyEdges[y][9])
+		}
+	}
+}
+
+func TestMapEdgesCannyHysteresis(t *testing.T) {
+	// A vertical step fading from strong at the top to weak at the bottom
+	connected := grayImage(20, 20, func(x, y int) uint8 {
+		if x < 10 {
+			return 0
+		}
+		return uint8(200 - 8*y)
+	})
+
+	edges, err := MapEdgesCanny(connected, Sobel, 0.05, 0.3, 4)
+	if err != nil {
+		t.Fatalf("Failed to map edges: %v", err)
+	}
+	for y := 1; y < 19; y++ {
+		vertical := 0
+		for _, e := range edges[y] {
+			if e == Vertical {
+				vertical++
+			}
+		}
+		if vertical != 1 {
+			t.Errorf("row %d: got %d vertical edge pixels, want 1", y, vertical)
+		}
+	}
+
+	// The same weak step on its own never reaches the high threshold
+	isolated := grayImage(20, 20, func(x, y int) uint8 {
+		if x < 10 {
+			return 0
+		}
+		return 40
+	})
+
+	edges, err = MapEdgesCanny(isolated, Sobel, 0.05, 0.3, 4)
+	if err != nil {
+		t.Fatalf("Failed to map edges: %v", err)
+	}
+	for y, row := range edges {
+		for x, e := range row {
+			if e != None && e != Default {
+				t.Errorf("isolated weak edge kept at %d,%d", x, y)
+			}
+		}
+	}
+
+	if _, err := MapEdgesCanny(isolated, Sobel, 0.3, 0.05, 4); err == nil {
+		t.Errorf("expected error with low threshold above high threshold")
+	}
+}
+
+func TestReduceEdgesTies(t *testing.T) {
+	// Two columns of each direction in a single 4x4 block
+	edges := [][]Edge{
+		{Vertical, Vertical, Horizontal, Horizontal},
+		{Vertical, Vertical, Horizontal, Horizontal},
+		{Vertical, Vertical, Horizontal, Horizontal},
+		{Vertical, Vertical, Horizontal, Horizontal},
+	}
+	for range 10 {
+		reduced, _, err := ReduceEdges(edges, 1, 1, 0)
+		if err != nil {
+			t.Fatalf("Failed to reduce edges: %v", err)
+		}
+		if reduced[0][0] != Horizontal {
+			t.Fatalf("got %s, want %s", reduced[0][0], Horizontal)
+		}
+	}
+}
+
+// Weighted edge map of a single 4x4 block from each pixel's edge angle in degrees and magnitude, with a magnitude
+// of 0 for None
+func weightedBlock(degrees, magnitude [4][4]float32) *WeightedEdges {
+	w := &WeightedEdges{}
+	for y := range 4 {
+		w.Edges = append(w.Edges, make([]Edge, 4))
+		w.Magnitude = append(w.Magnitude, make([]float32, 4))
+		w.Angle = append(w.Angle, make([]float32, 4))
+		for x := range 4 {
+			if magnitude[y][x] == 0 {
+				w.Edges[y][x] = None
+				continue
+			}
+			rad := float64(degrees[y][x]) * math.Pi / 180
+			w.Edges[y][x] = xyToOrientation(float32(math.Cos(rad)), float32(math.Sin(rad)), 0, 16)
+			w.Magnitude[y][x] = magnitude[y][x]
+			w.Angle[y][x] = float32(rad)
+		}
+	}
+	return w
+}
+
+func TestReduceWeightedEdges(t *testing.T) {
+	testData := []struct {
+		name        string
+		degrees     [4][4]float32
+		magnitude   [4][4]float32
+		circular    bool
+		want        Edge
+		wantDensity float32
+	}{
+		{
+			name:      "strong contour outweighs faint texture",
+			degrees:   [4][4]float32{{90, 0, 0, 0}, {90, 0, 0, 0}, {90, 0, 0, 0}, {90, 0, 0, 0}},
+			magnitude: [4][4]float32{{1, 0.1, 0.1, 0.1}, {1, 0.1, 0.1, 0.1}, {1, 0.1, 0.1, 0.1}, {1, 0.1, 0.1, 0.1}},
+			want:      Vertical, wantDensity: 0.25,
+		},
+		{
+			name:      "faint block falls below threshold",
+			degrees:   [4][4]float32{},
+			magnitude: [4][4]float32{{0.1, 0.1, 0.1, 0.1}, {0.1, 0.1, 0.1, 0.1}, {0.1, 0.1, 0.1, 0.1}, {0.1, 0.1, 0.1, 0.1}},
+			want:      None, wantDensity: 0.1,
+		},
+		{
+			name:      "circular mean between two bins",
+			degrees:   [4][4]float32{{30, 60, 30, 60}, {30, 60, 30, 60}, {30, 60, 30, 60}, {30, 60, 30, 60}},
+			magnitude: [4][4]float32{{1, 1, 1, 1}, {1, 1, 1, 1}, {1, 1, 1, 1}, {1, 1, 1, 1}},
+			circular:  true,
+			want:      DiagonalUp, wantDensity: float32(math.Cos(math.Pi / 6)),
+		},
+		{
+			name:      "circular mean wraps around horizontal",
+			degrees:   [4][4]float32{{5, 175, 5, 175}, {5, 175, 5, 175}, {5, 175, 5, 175}, {5, 175, 5, 175}},
+			magnitude: [4][4]float32{{1, 1, 1, 1}, {1, 1, 1, 1}, {1, 1, 1, 1}, {1, 1, 1, 1}},
+			circular:  true,
+			want:      Horizontal, wantDensity: float32(math.Cos(math.Pi / 18)),
+		},
+		{
+			name:      "perpendicular angles cancel",
+			degrees:   [4][4]float32{{0, 90, 0, 90}, {0, 90, 0, 90}, {0, 90, 0, 90}, {0, 90, 0, 90}},
+			magnitude: [4][4]float32{{1, 1, 1, 1}, {1, 1, 1, 1}, {1, 1, 1, 1}, {1, 1, 1, 1}},
+			circular:  true,
+			want:      None, wantDensity: 0,
+		},
+	}
+
+	for _, d := range testData {
+		t.Run(d.name, func(t *testing.T) {
+			reduced, density, err := ReduceWeightedEdges(weightedBlock(d.degrees, d.magnitude), 1, 1, 0.2, 16, d.circular)
+			if err != nil {
+				t.Fatalf("Failed to reduce edges: %v", err)
+			}
+			if reduced[0][0] != d.want {
+				t.Errorf("got %s, want %s", reduced[0][0], d.want)
+			}
+			if math.Abs(float64(density[0][0]-d.wantDensity)) > 1e-5 {
+				t.Errorf("got density %f, want %f", density[0][0], d.wantDensity)
+			}
+		})
+	}
+}
+
+func TestEmptyGrids(t *testing.T) {
+	row := [][]rune{[]rune("ab")}
+
+	testData := []struct {
+		name string
+		err  error
+		want error
+	}{
+		{"overlay onto nil base", overlayErr(nil, row), ErrEmptyGrid},
+		{"overlay nil edges", overlayErr(row, nil), ErrEmptyGrid},
+		{"overlay empty rows", overlayErr([][]rune{{}}, [][]rune{{}}), ErrEmptyGrid},
+		{"overlay mismatched", overlayErr(row, [][]rune{[]rune("abc")}), ErrMismatchedDimensions},
+		{"overlay ragged", overlayErr([][]rune{[]rune("ab"), []rune("a")}, [][]rune{[]rune("ab"), []rune("ab")}), ErrMismatchedDimensions},
+		{"reduce nil", reduceErr(nil), ErrEmptyGrid},
+		{"reduce empty rows", reduceErr([][]Edge{{}, {}}), ErrEmptyGrid},
+		{"reduce bad width", func() error {
+			_, _, err := ReduceEdges([][]Edge{{None}}, 0, 1, 0.5)
+			return err
+		}(), ErrInvalidOption},
+	}
+
+	for _, d := range testData {
+		if !errors.Is(d.err, d.want) {
+			t.Errorf("%s: got error %v, want %v", d.name, d.err, d.want)
+		}
+	}
+
+	_, _, err := ReduceWeightedEdges(&WeightedEdges{}, 10, 1, 0.5, 4, false)
+	if !errors.Is(err, ErrEmptyGrid) {
+		t.Errorf("reduce weighted nil: got error %v, want %v", err, ErrEmptyGrid)
+	}
+}
+
+func overlayErr(base, edges [][]rune) error {
+	_, err := OverlayEdges(base, edges)
+	return err
+}
+
+func reduceErr(edges [][]Edge) error {
+	_, _, err := ReduceEdges(edges, 10, 1, 0.5)
+	return err
+}
@@ -0,0 +1,37 @@
+package asciiart
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// Wrapped by every *OptionError, for checking with errors.Is
+	ErrInvalidOption = errors.New("invalid option")
+
+	// An edge or character grid without any cells was given where one is needed
+	ErrEmptyGrid = errors.New("empty grid")
+
+	// Grids that must line up cell for cell have different dimensions
+	ErrMismatchedDimensions = errors.New("mismatched dimensions")
+)
+
+// An option or parameter outside of what the pipeline accepts. Field names the Converter field, options struct field
+// (e.g. DoGOptions.Sigma1) or function parameter at fault.
+type OptionError struct {
+	Field  string
+	Value  any
+	Reason string
+}
+
+func (e *OptionError) Error() string {
+	return fmt.Sprintf("invalid %s %v: %s", e.Field, e.Value, e.Reason)
+}
+
+func (e *OptionError) Unwrap() error {
+	return ErrInvalidOption
+}
+
+func optionError(field string, value any, reason string) *OptionError {
+	return &OptionError{Field: field, Value: value, Reason: reason}
+}
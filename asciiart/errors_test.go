@@ -0,0 +1,46 @@
+package asciiart
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// Fields of every *OptionError in err, looking through joined and wrapped errors
+func optionFields(err error) []string {
+	var fields []string
+	switch e := err.(type) {
+	case nil:
+	case *OptionError:
+		fields = append(fields, e.Field)
+	case interface{ Unwrap() []error }:
+		for _, err := range e.Unwrap() {
+			fields = append(fields, optionFields(err)...)
+		}
+	case interface{ Unwrap() error }:
+		fields = optionFields(e.Unwrap())
+	}
+	return fields
+}
+
+func TestOptionError(t *testing.T) {
+	err := fmt.Errorf("converting: %w", optionError("CharWidth", -1, "must be positive"))
+
+	if !errors.Is(err, ErrInvalidOption) {
+		t.Error("OptionError should match ErrInvalidOption")
+	}
+	if errors.Is(err, ErrEmptyGrid) {
+		t.Error("OptionError shouldn't match ErrEmptyGrid")
+	}
+
+	var oe *OptionError
+	if !errors.As(err, &oe) {
+		t.Fatal("expected to find the OptionError")
+	}
+	if oe.Field != "CharWidth" || oe.Value != -1 || oe.Reason != "must be positive" {
+		t.Errorf("got %+v", oe)
+	}
+	if want := "converting: invalid CharWidth -1: must be positive"; err.Error() != want {
+		t.Errorf("got message %q, want %q", err.Error(), want)
+	}
+}
@@ -0,0 +1,206 @@
+package asciiart
+
+import (
+	"errors"
+	"image"
+	"math"
+)
+
+type FlowOptions struct {
+	TensorSigma float32 // Standard deviation of the Gaussian smoothing the structure tensor, which evens out the flow
+	FlowSigma   float32 // Standard deviation of the Gaussian blur along the flow curves, which joins up lines
+}
+
+func validateFlowOptions(opts FlowOptions) error {
+	var errs []error
+	if opts.TensorSigma <= 0 {
+		errs = append(errs, optionError("FlowOptions.TensorSigma", opts.TensorSigma, "must be positive"))
+	}
+	if opts.FlowSigma <= 0 {
+		errs = append(errs, optionError("FlowOptions.FlowSigma", opts.FlowSigma, "must be positive"))
+	}
+	return errors.Join(errs...)
+}
+
+// Normalized 1d Gaussian kernel from -r to r, with r three standard deviations
+func gaussianKernel(sigma float32) []float32 {
+	r := int(math.Ceil(float64(3 * sigma)))
+	kernel := make([]float32, 2*r+1)
+	var sum float32
+	for i := range kernel {
+		d := float64(i - r)
+		kernel[i] = float32(math.Exp(-d * d / (2 * float64(sigma*sigma))))
+		sum += kernel[i]
+	}
+	for i := range kernel {
+		kernel[i] /= sum
+	}
+	return kernel
+}
+
+// Bilinearly interpolated value of a row-major float image at x, y, clamping at the borders
+func sampleBilinear(src []float32, width, height int, x, y float64) float32 {
+	x = min(max(x, 0), float64(width-1))
+	y = min(max(y, 0), float64(height-1))
+	x0 := int(x)
+	y0 := int(y)
+	x1 := min(x0+1, width-1)
+	y1 := min(y0+1, height-1)
+	fx := float32(x - float64(x0))
+	fy := float32(y - float64(y0))
+
+	top := src[y0*width+x0]*(1-fx) + src[y0*width+x1]*fx
+	bottom := src[y1*width+x0]*(1-fx) + src[y1*width+x1]*fx
+	return top*(1-fy) + bottom*fy
+}
+
+// Edge tangent flow of a grayscale image: the unit direction of least change at every pixel, from the minor
+// eigenvector of the structure tensor smoothed with a Gaussian of the given sigma. Flat regions flow horizontally.
+func tangentFlow(img *image.Gray, sigma float32, workers int) (tx, ty []float32) {
+	width := img.Bounds().Dx()
+	height := img.Bounds().Dy()
+	gx, gy := detectGradients(Sobel, img, workers)
+
+	e := make([]float32, width*height)
+	f := make([]float32, width*height)
+	g := make([]float32, width*height)
+	for i := range gx {
+		e[i] = gx[i] * gx[i]
+		f[i] = gx[i] * gy[i]
+		g[i] = gy[i] * gy[i]
+	}
+	e = gaussianBlur(e, width, height, sigma, workers)
+	f = gaussianBlur(f, width, height, sigma, workers)
+	g = gaussianBlur(g, width, height, sigma, workers)
+
+	tx = make([]float32, width*height)
+	ty = make([]float32, width*height)
+	for i := range tx {
+		// Major eigenvalue of [[E, F], [F, G]]; its eigenvector (F, λ - E) points across the edge
+		half := (e[i] - g[i]) / 2
+		lambda := (e[i]+g[i])/2 + float32(math.Sqrt(float64(half*half+f[i]*f[i])))
+		x, y := lambda-e[i], -f[i]
+		if x == 0 && y == 0 {
+			// E is already the major eigenvalue, so the gradient runs along x; or there is no gradient at all
+			x, y = 0, 1
+			if e[i] <= g[i] {
+				x, y = 1, 0
+			}
+		}
+		n := float32(math.Hypot(float64(x), float64(y)))
+		tx[i] = x / n
+		ty[i] = y / n
+	}
+	return tx, ty
+}
+
+// Apply the flow-based Difference of Gaussians as preprocessor for edge detection: the XDoG operator of opts is
+// taken in one dimension across the edge tangent flow, then smoothed along the flow curves, so that lines follow
+// curved contours instead of breaking up like the isotropic DoG's
+func FDoG(img image.Image, opts DoGOptions, flow FlowOptions) (*image.Gray, error) {
+	return fDoG(img, opts, flow, 0, nil)
+}
+
+func fDoG(img image.Image, opts DoGOptions, flow FlowOptions, workers int, s *stage) (*image.Gray, error) {
+	err := validateDoGOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+	err = validateFlowOptions(flow)
+	if err != nil {
+		return nil, err
+	}
+
+	// Edge detectors index from the origin
+	gray := grayscale(img, workers)
+	gray.Rect = gray.Rect.Sub(gray.Rect.Min)
+	width := gray.Bounds().Dx()
+	height := gray.Bounds().Dy()
+
+	lum := make([]float32, width*height)
+	for y := range height {
+		for x := range width {
+			lum[y*width+x] = float32(gray.Pix[y*gray.Stride+x]) / 255
+		}
+	}
+	tx, ty := tangentFlow(gray, flow.TensorSigma, workers)
+	if err := s.err(); err != nil {
+		return nil, err
+	}
+
+	// Winnemoller's XDoG operator, e.g. (1 + τ) * G_1 - τ * G_2, as a single kernel across the flow
+	k1 := gaussianKernel(opts.Sigma1)
+	k2 := gaussianKernel(opts.sigma2())
+	w1, w2 := opts.weights()
+	r := max(len(k1), len(k2)) / 2
+	kernel := make([]float32, 2*r+1)
+	for i, w := range k1 {
+		kernel[i+r-len(k1)/2] += w1 * w
+	}
+	for i, w := range k2 {
+		kernel[i+r-len(k2)/2] -= w2 * w
+	}
+
+	across := make([]float32, width*height)
+	parallelRows(height, workers, func(y0, y1 int) {
+		for y := y0; y < y1; y++ {
+			for x := range width {
+				i := y*width + x
+				// The gradient direction is perpendicular to the tangent
+				nx, ny := float64(-ty[i]), float64(tx[i])
+				var v float32
+				for k, w := range kernel {
+					d := float64(k - r)
+					v += w * sampleBilinear(lum, width, height, float64(x)+d*nx, float64(y)+d*ny)
+				}
+				across[i] = v
+			}
+		}
+	})
+	if err := s.err(); err != nil {
+		return nil, err
+	}
+
+	// Line integral convolution: follow the flow a pixel at a time in both directions from each pixel
+	km := gaussianKernel(flow.FlowSigma)
+	rm := len(km) / 2
+	dst := image.NewGray(image.Rect(0, 0, width, height))
+	err = s.rows(height, workers, func(y int) {
+		for x := range width {
+			i := y*width + x
+			sum := km[rm] * across[i]
+			weight := km[rm]
+			for _, sign := range []float32{1, -1} {
+				px, py := float64(x), float64(y)
+				dx, dy := sign*tx[i], sign*ty[i]
+				for step := 1; step <= rm; step++ {
+					px += float64(dx)
+					py += float64(dy)
+					if px < 0 || py < 0 || px > float64(width-1) || py > float64(height-1) {
+						break
+					}
+					sum += km[rm+step] * sampleBilinear(across, width, height, px, py)
+					weight += km[rm+step]
+
+					// Keep heading the same way as the flow turns, since tangents have no direction
+					j := int(math.Round(py))*width + int(math.Round(px))
+					if tx[j]*dx+ty[j]*dy < 0 {
+						dx, dy = -tx[j], -ty[j]
+					} else {
+						dx, dy = tx[j], ty[j]
+					}
+				}
+			}
+
+			d := opts.threshold(sum / weight)
+			dst.Pix[y*dst.Stride+x] = uint8(math.Round(255 * float64(d)))
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Keep the source's bounds like DoG does
+	dst.Rect = dst.Rect.Add(img.Bounds().Min)
+	return dst, nil
+}
@@ -0,0 +1,88 @@
+package asciiart
+
+import (
+	"math"
+	"testing"
+)
+
+func TestTangentFlow(t *testing.T) {
+	testData := []struct {
+		name   string
+		f      func(x, y int) uint8
+		wantTx float64
+		wantTy float64
+	}{
+		{
+			name:   "vertical step",
+			f:      func(x, y int) uint8 { return uint8(255 * min(max(x-19, 0), 1)) },
+			wantTx: 0, wantTy: 1,
+		},
+		{
+			name:   "horizontal step",
+			f:      func(x, y int) uint8 { return uint8(255 * min(max(y-19, 0), 1)) },
+			wantTx: 1, wantTy: 0,
+		},
+		{
+			name:   "diagonal step",
+			f:      func(x, y int) uint8 { return uint8(255 * min(max(x-y, 0), 1)) },
+			wantTx: math.Sqrt2 / 2, wantTy: math.Sqrt2 / 2,
+		},
+	}
+
+	for _, d := range testData {
+		t.Run(d.name, func(t *testing.T) {
+			img := grayImage(40, 40, d.f)
+			tx, ty := tangentFlow(img, 2, 0)
+
+			// Tangents have no direction, so compare up to sign
+			i := 20*40 + 20
+			dot := math.Abs(float64(tx[i])*d.wantTx + float64(ty[i])*d.wantTy)
+			if dot < 0.99 {
+				t.Errorf("got tangent %.3f,%.3f, want %.3f,%.3f", tx[i], ty[i], d.wantTx, d.wantTy)
+			}
+		})
+	}
+}
+
+func TestFDoG(t *testing.T) {
+	// Dark disc on white, whose outline the flow follows around
+	disc := grayImage(80, 80, func(x, y int) uint8 {
+		if math.Hypot(float64(x-40), float64(y-40)) < 20 {
+			return 0
+		}
+		return 255
+	})
+	opts := DoGOptions{Sigma1: 1, Sigma2: 2, Epsilon: 0.65, Tau: 0.8, Phi: 25}
+
+	res, err := FDoG(disc, opts, FlowOptions{TensorSigma: 2, FlowSigma: 3})
+	if err != nil {
+		t.Fatalf("Failed to perform FDoG: %v", err)
+	}
+	if res.Bounds() != disc.Bounds() {
+		t.Fatalf("got bounds %v, want %v", res.Bounds(), disc.Bounds())
+	}
+
+	// Far from the outline the image stays white, while every angle around it has a dark line
+	if res.GrayAt(5, 5).Y != 255 {
+		t.Errorf("got %d away from the disc, want 255", res.GrayAt(5, 5).Y)
+	}
+	for deg := 0; deg < 360; deg += 15 {
+		rad := float64(deg) * math.Pi / 180
+		darkest := uint8(255)
+		for r := 16.0; r <= 24; r++ {
+			x := 40 + int(math.Round(r*math.Cos(rad)))
+			y := 40 + int(math.Round(r*math.Sin(rad)))
+			darkest = min(darkest, res.GrayAt(x, y).Y)
+		}
+		if darkest > 64 {
+			t.Errorf("no line at %d degrees, darkest pixel is %d", deg, darkest)
+		}
+	}
+
+	if _, err := FDoG(disc, opts, FlowOptions{TensorSigma: 0, FlowSigma: 3}); err == nil {
+		t.Error("expected error for non-positive tensor sigma")
+	}
+	if _, err := FDoG(disc, opts, FlowOptions{TensorSigma: 2, FlowSigma: -1}); err == nil {
+		t.Error("expected error for non-positive flow sigma")
+	}
+}
@@ -0,0 +1,133 @@
+package asciiart
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+var (
+	// Edge glyphs using only printable ASCII: the original four, with a comma and backtick for shallow slopes, parentheses
+	// for steep ones (read by their upper half) and dashes for the nearly flat ones, so that each of 8 bins has a glyph
+	// of its own
+	ASCIIEdgeGlyphs = map[Edge]rune{
+		Horizontal:       '_',
+		FlatUp:           '-',
+		ShallowUp:        ',',
+		LowDiagonalUp:    '/',
+		DiagonalUp:       '/',
+		HighDiagonalUp:   '/',
+		SteepUp:          '(',
+		NearVerticalUp:   '|',
+		Vertical:         '|',
+		NearVerticalDown: '|',
+		SteepDown:        ')',
+		HighDiagonalDown: '\\',
+		DiagonalDown:     '\\',
+		LowDiagonalDown:  '\\',
+		ShallowDown:      '`',
+		FlatDown:         '-',
+		Corner:           '+',
+	}
+
+	// Edge glyphs drawn with box drawing lines, which join up across cells in most terminal fonts
+	UnicodeEdgeGlyphs = map[Edge]rune{
+		Horizontal:       '─',
+		FlatUp:           '─',
+		ShallowUp:        '─',
+		LowDiagonalUp:    '╱',
+		DiagonalUp:       '╱',
+		HighDiagonalUp:   '╱',
+		SteepUp:          '╱',
+		NearVerticalUp:   '│',
+		Vertical:         '│',
+		NearVerticalDown: '│',
+		SteepDown:        '╲',
+		HighDiagonalDown: '╲',
+		DiagonalDown:     '╲',
+		LowDiagonalDown:  '╲',
+		ShallowDown:      '─',
+		FlatDown:         '─',
+		Corner:           '┼',
+	}
+)
+
+// Look up an edge glyph table by name as accepted by the CLI
+func ParseEdgeGlyphs(name string) (map[Edge]rune, error) {
+	switch name {
+	case "", "ascii":
+		return ASCIIEdgeGlyphs, nil
+	case "unicode":
+		return UnicodeEdgeGlyphs, nil
+	default:
+		return nil, fmt.Errorf("unknown edge glyph set: %q", name)
+	}
+}
+
+// Parse a comma separated list of name=glyph pairs, e.g. "horizontal=─,vertical=│", into a copy of base with those
+// glyphs replaced. Each glyph is a single character, which may itself be a comma or equals sign.
+func ParseEdgeGlyphSpec(spec string, base map[Edge]rune) (map[Edge]rune, error) {
+	glyphs := make(map[Edge]rune, len(base))
+	for e, g := range base {
+		glyphs[e] = g
+	}
+
+	rest := []rune(spec)
+	for len(rest) > 0 {
+		eq := slices.Index(rest, '=')
+		if eq < 0 {
+			return nil, fmt.Errorf("edge glyph %q is missing '='", string(rest))
+		}
+		e, err := ParseEdge(strings.TrimSpace(string(rest[:eq])))
+		if err != nil {
+			return nil, err
+		}
+		if e == None || e == Default {
+			return nil, fmt.Errorf("%s cells are never drawn and can't be given a glyph", e)
+		}
+		if eq+1 >= len(rest) {
+			return nil, fmt.Errorf("edge glyph for %s is empty", e)
+		}
+		glyphs[e] = rest[eq+1]
+
+		rest = rest[eq+2:]
+		if len(rest) > 0 {
+			if rest[0] != ',' {
+				return nil, fmt.Errorf("edge glyph for %s must be a single character", e)
+			}
+			rest = rest[1:]
+		}
+	}
+	return glyphs, nil
+}
+
+// Check that every orientation produced with bins classes, and Corner if corners are detected, has a visible glyph
+func validateEdgeGlyphs(glyphs map[Edge]rune, bins int, corners bool) error {
+	if err := validateOrientationBins(bins); err != nil {
+		return err
+	}
+
+	required := binOrientations(bins)
+	if corners {
+		required = append(required, Corner)
+	}
+
+	// Cells without an edge are always left blank
+	for _, e := range []Edge{None, Default} {
+		if _, ok := glyphs[e]; ok {
+			return optionError("EdgeGlyphs", e, "has a glyph for cells without an edge")
+		}
+	}
+
+	var missing []string
+	for _, e := range required {
+		if g, ok := glyphs[e]; !ok || unicode.IsSpace(g) || !unicode.IsPrint(g) {
+			missing = append(missing, e.String())
+		}
+	}
+	if len(missing) > 0 {
+		return optionError("EdgeGlyphs", strings.Join(missing, ", "), "missing a printable glyph")
+	}
+	return nil
+}
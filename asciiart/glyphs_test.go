@@ -0,0 +1,140 @@
+package asciiart
+
+import "testing"
+
+func TestEdgeGlyphTables(t *testing.T) {
+	for _, name := range []string{"ascii", "unicode"} {
+		t.Run(name, func(t *testing.T) {
+			glyphs, err := ParseEdgeGlyphs(name)
+			if err != nil {
+				t.Fatalf("Failed to parse glyph set: %v", err)
+			}
+			if err := validateEdgeGlyphs(glyphs, 16, true); err != nil {
+				t.Errorf("incomplete glyph set: %v", err)
+			}
+		})
+	}
+
+	// Every orientation of 8 bins is drawn differently in ASCII
+	seen := map[rune]Edge{}
+	for _, e := range binOrientations(8) {
+		g := ASCIIEdgeGlyphs[e]
+		if other, ok := seen[g]; ok {
+			t.Errorf("%s and %s share the glyph %q", other, e, g)
+		}
+		seen[g] = e
+	}
+
+	if _, err := ParseEdgeGlyphs("braille"); err == nil {
+		t.Error("expected error for unknown glyph set")
+	}
+}
+
+func TestEdgesToGlyphs(t *testing.T) {
+	edges := [][]Edge{
+		{Default, None, Horizontal},
+		{SteepUp, Vertical, ShallowDown},
+	}
+	glyphs := map[Edge]rune{Horizontal: '=', Vertical: '!', SteepUp: ')', None: '#', Default: '#'}
+
+	res := EdgesToGlyphs(edges, glyphs)
+	// Cells without an edge stay blank even when the table has a glyph for them
+	want := []string{"  =", ")! "}
+	for y, row := range res {
+		if string(row) != want[y] {
+			t.Errorf("row %d: got %q, want %q", y, string(row), want[y])
+		}
+	}
+
+	// The default table keeps the original four glyphs
+	res = EdgesToASCII([][]Edge{{Horizontal, DiagonalUp, Vertical, DiagonalDown}})
+	if string(res[0]) != `_/|\` {
+		t.Errorf("got %q, want %q", string(res[0]), `_/|\`)
+	}
+}
+
+func TestParseEdge(t *testing.T) {
+	for e := range edgeNames {
+		res, err := ParseEdge(e.String())
+		if err != nil || res != e {
+			t.Errorf("round trip of %s: got %s, %v", e, res, err)
+		}
+	}
+
+	if _, err := ParseEdge("sideways"); err == nil {
+		t.Error("expected error for unknown edge")
+	}
+}
+
+func TestParseEdgeGlyphSpec(t *testing.T) {
+	testData := []struct {
+		spec    string
+		want    map[Edge]rune
+		wantErr bool
+	}{
+		{"", map[Edge]rune{Horizontal: '_'}, false},
+		{"vertical=│", map[Edge]rune{Horizontal: '_', Vertical: '│'}, false},
+		{"horizontal=─, vertical=│", map[Edge]rune{Horizontal: '─', Vertical: '│'}, false},
+		{"shallow-up=,,shallow-down==", map[Edge]rune{Horizontal: '_', ShallowUp: ',', ShallowDown: '='}, false},
+		{"vertical", nil, true},
+		{"vertical=", nil, true},
+		{"vertical=||", nil, true},
+		{"sideways=|", nil, true},
+		{"none=#", nil, true},
+		{"vertical=|,default=#", nil, true},
+	}
+
+	base := map[Edge]rune{Horizontal: '_'}
+	for _, d := range testData {
+		t.Run(d.spec, func(t *testing.T) {
+			res, err := ParseEdgeGlyphSpec(d.spec, base)
+			if d.wantErr {
+				if err == nil {
+					t.Errorf("expected error, got %v", res)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Failed to parse spec: %v", err)
+			}
+			if len(res) != len(d.want) {
+				t.Errorf("got %v, want %v", res, d.want)
+			}
+			for e, g := range d.want {
+				if res[e] != g {
+					t.Errorf("%s: got %q, want %q", e, res[e], g)
+				}
+			}
+		})
+	}
+
+	if base[Horizontal] != '_' || len(base) != 1 {
+		t.Errorf("base table was modified: %v", base)
+	}
+}
+
+func TestValidateEdgeGlyphs(t *testing.T) {
+	four := map[Edge]rune{Horizontal: '_', Vertical: '|', DiagonalUp: '/', DiagonalDown: '\\'}
+
+	if err := validateEdgeGlyphs(four, 4, false); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := validateEdgeGlyphs(four, 8, false); err == nil {
+		t.Error("expected error for missing 8 bin orientations")
+	}
+	if err := validateEdgeGlyphs(UnicodeEdgeGlyphs, 16, false); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	blank := map[Edge]rune{Horizontal: ' ', Vertical: '|', DiagonalUp: '/', DiagonalDown: '\\'}
+	if err := validateEdgeGlyphs(blank, 4, false); err == nil {
+		t.Error("expected error for blank glyph")
+	}
+
+	for _, e := range []Edge{None, Default} {
+		filled := map[Edge]rune{e: '#', Horizontal: '_', Vertical: '|', DiagonalUp: '/', DiagonalDown: '\\'}
+		if err := validateEdgeGlyphs(filled, 4, false); err == nil {
+			t.Errorf("expected error for a %s glyph", e)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package asciiart
+
+import (
+	"bufio"
+	"fmt"
+	"html"
+	"image/color"
+	"io"
+)
+
+// Renders the characters into a monospace <pre> element, either as a self-contained document or a fragment
+type HTMLRenderer struct {
+	Fragment bool   // only emit the <pre> element instead of a full document
+	Color    bool   // wrap runs of same-colored cells in spans using the result's cell colors
+	Title    string // document title, ignored for fragments
+}
+
+func (h HTMLRenderer) Render(w io.Writer, r *Result) error {
+	if h.Color {
+		if _, err := resultColors(r); err != nil {
+			return err
+		}
+	}
+
+	bw := bufio.NewWriter(w)
+	if !h.Fragment {
+		bw.WriteString("<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n")
+		fmt.Fprintf(bw, "<title>%s</title>\n", html.EscapeString(h.Title))
+		bw.WriteString("<style>body{background:#000;color:#fff}</style>\n</head>\n<body>\n")
+	}
+
+	bw.WriteString("<pre style=\"font-family:monospace;line-height:1\">")
+	for y, row := range r.Art {
+		if h.Color {
+			writeHTMLColorRow(bw, row, r, y)
+		} else {
+			bw.WriteString(html.EscapeString(string(row)))
+		}
+		bw.WriteByte('\n')
+	}
+	bw.WriteString("</pre>\n")
+
+	if !h.Fragment {
+		bw.WriteString("</body>\n</html>\n")
+	}
+	return bw.Flush()
+}
+
+// Writes a row as one span per run of cells sharing a color
+func writeHTMLColorRow(bw *bufio.Writer, row []rune, r *Result, y int) {
+	colorRuns(r, y, func(start, end int, c color.RGBA) {
+		fmt.Fprintf(bw, "<span style=\"color:%s\">%s</span>", hexColor(c), html.EscapeString(string(row[start:end])))
+	})
+}
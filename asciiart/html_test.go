@@ -0,0 +1,50 @@
+package asciiart
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"strings"
+	"testing"
+)
+
+func TestHTMLRendererEscaping(t *testing.T) {
+	r := &Result{Art: [][]rune{[]rune("<a&b>\\")}}
+
+	var buf bytes.Buffer
+	err := HTMLRenderer{Fragment: true}.Render(&buf, r)
+	if err != nil {
+		t.Fatalf("Failed to render: %v", err)
+	}
+
+	want := "<pre style=\"font-family:monospace;line-height:1\">&lt;a&amp;b&gt;\\\n</pre>\n"
+	if buf.String() != want {
+		t.Errorf("got %q, want %q", buf.String(), want)
+	}
+}
+
+func TestHTMLRendererColorRuns(t *testing.T) {
+	colors := image.NewRGBA(image.Rect(0, 0, 4, 1))
+	colors.SetRGBA(0, 0, color.RGBA{255, 0, 0, 255})
+	colors.SetRGBA(1, 0, color.RGBA{255, 0, 0, 255})
+	colors.SetRGBA(2, 0, color.RGBA{0, 128, 255, 255})
+	colors.SetRGBA(3, 0, color.RGBA{0, 128, 255, 255})
+	r := &Result{Art: [][]rune{[]rune("ab<c")}, Colors: colors}
+
+	var buf bytes.Buffer
+	err := HTMLRenderer{Color: true, Title: "a & b"}.Render(&buf, r)
+	if err != nil {
+		t.Fatalf("Failed to render: %v", err)
+	}
+
+	res := buf.String()
+	for _, want := range []string{
+		"<title>a &amp; b</title>",
+		"<span style=\"color:#ff0000\">ab</span><span style=\"color:#0080ff\">&lt;c</span>\n",
+		"</html>\n",
+	} {
+		if !strings.Contains(res, want) {
+			t.Errorf("output %q does not contain %q", res, want)
+		}
+	}
+}
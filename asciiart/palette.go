@@ -0,0 +1,166 @@
+package asciiart
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+)
+
+// Color in CIELAB space, where euclidean distance approximates perceived difference
+type lab struct {
+	L, A, B float64
+}
+
+// Palette of ANSI colors with the escape selecting each entry
+type ansiPalette struct {
+	colors []color.RGBA
+	labs   []lab
+	sgr    func(i int) string
+}
+
+// xterm-256 6x6x6 color cube and 24-step grayscale ramp (indices 16-255); the first 16 entries are left out since
+// terminals are free to remap them
+var xterm256 = newANSIPalette(xterm256Colors(), func(i int) string {
+	return fmt.Sprintf("\x1b[38;5;%dm", i+16)
+})
+
+// Basic 16 ANSI colors using xterm's default RGB values
+var ansi16 = newANSIPalette([]color.RGBA{
+	{0, 0, 0, 255}, {205, 0, 0, 255}, {0, 205, 0, 255}, {205, 205, 0, 255},
+	{0, 0, 238, 255}, {205, 0, 205, 255}, {0, 205, 205, 255}, {229, 229, 229, 255},
+	{127, 127, 127, 255}, {255, 0, 0, 255}, {0, 255, 0, 255}, {255, 255, 0, 255},
+	{92, 92, 255, 255}, {255, 0, 255, 255}, {0, 255, 255, 255}, {255, 255, 255, 255},
+}, func(i int) string {
+	if i < 8 {
+		return fmt.Sprintf("\x1b[%dm", 30+i)
+	}
+	return fmt.Sprintf("\x1b[%dm", 90+i-8)
+})
+
+func xterm256Colors() []color.RGBA {
+	levels := [6]uint8{0, 95, 135, 175, 215, 255}
+	colors := make([]color.RGBA, 0, 240)
+	for r := range 6 {
+		for g := range 6 {
+			for b := range 6 {
+				colors = append(colors, color.RGBA{levels[r], levels[g], levels[b], 255})
+			}
+		}
+	}
+	for i := range 24 {
+		v := uint8(8 + 10*i)
+		colors = append(colors, color.RGBA{v, v, v, 255})
+	}
+	return colors
+}
+
+func newANSIPalette(colors []color.RGBA, sgr func(i int) string) *ansiPalette {
+	labs := make([]lab, len(colors))
+	for i, c := range colors {
+		labs[i] = rgbToLab(c)
+	}
+	return &ansiPalette{colors: colors, labs: labs, sgr: sgr}
+}
+
+// Palette to quantize to for a color mode, nil if the mode is not palette based
+func paletteFor(mode ColorMode) *ansiPalette {
+	switch mode {
+	case ANSI256:
+		return xterm256
+	case ANSI16:
+		return ansi16
+	default:
+		return nil
+	}
+}
+
+// Index of the palette entry closest to l
+func (p *ansiPalette) nearest(l lab) int {
+	best := 0
+	bestDist := math.Inf(1)
+	for i, pl := range p.labs {
+		dl, da, db := l.L-pl.L, l.A-pl.A, l.B-pl.B
+		dist := dl*dl + da*da + db*db
+		if dist < bestDist {
+			best = i
+			bestDist = dist
+		}
+	}
+	return best
+}
+
+// Undo the sRGB transfer curve
+func linearize(v uint8) float64 {
+	c := float64(v) / 255
+	if c <= 0.04045 {
+		return c / 12.92
+	}
+	return math.Pow((c+0.055)/1.055, 2.4)
+}
+
+// Convert an sRGB color to CIELAB under the D65 white point
+func rgbToLab(c color.RGBA) lab {
+	r, g, b := linearize(c.R), linearize(c.G), linearize(c.B)
+
+	x := (0.4124564*r + 0.3575761*g + 0.1804375*b) / 0.95047
+	y := 0.2126729*r + 0.7151522*g + 0.0721750*b
+	z := (0.0193339*r + 0.1191920*g + 0.9503041*b) / 1.08883
+
+	f := func(t float64) float64 {
+		if t > 216.0/24389 {
+			return math.Cbrt(t)
+		}
+		return (24389.0/27*t + 16) / 116
+	}
+	fx, fy, fz := f(x), f(y), f(z)
+
+	return lab{L: 116*fy - 16, A: 500 * (fx - fy), B: 200 * (fy - fz)}
+}
+
+// Quantize colors to the palette of mode, diffusing the quantization error of each cell onto its unvisited
+// neighbors (Floyd-Steinberg). The result only contains palette colors, so printing it in the same mode is exact.
+func DitherColors(colors *image.RGBA, mode ColorMode) (*image.RGBA, error) {
+	p := paletteFor(mode)
+	if p == nil {
+		return nil, fmt.Errorf("dithering requires a palette color mode")
+	}
+
+	bounds := colors.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+
+	// Work on a copy in Lab space so the error is spread perceptually
+	buf := make([]lab, width*height)
+	for y := range height {
+		for x := range width {
+			buf[y*width+x] = rgbToLab(colors.RGBAAt(bounds.Min.X+x, bounds.Min.Y+y))
+		}
+	}
+
+	spread := func(x, y int, e lab, weight float64) {
+		if x < 0 || x >= width || y >= height {
+			return
+		}
+		l := &buf[y*width+x]
+		l.L += e.L * weight
+		l.A += e.A * weight
+		l.B += e.B * weight
+	}
+
+	dst := image.NewRGBA(bounds)
+	for y := range height {
+		for x := range width {
+			old := buf[y*width+x]
+			i := p.nearest(old)
+			dst.SetRGBA(bounds.Min.X+x, bounds.Min.Y+y, p.colors[i])
+
+			e := lab{old.L - p.labs[i].L, old.A - p.labs[i].A, old.B - p.labs[i].B}
+			spread(x+1, y, e, 7.0/16)
+			spread(x-1, y+1, e, 3.0/16)
+			spread(x, y+1, e, 5.0/16)
+			spread(x+1, y+1, e, 1.0/16)
+		}
+	}
+
+	return dst, nil
+}
@@ -0,0 +1,87 @@
+package asciiart
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"testing"
+)
+
+func TestRGBToLab(t *testing.T) {
+	testData := []struct {
+		c    color.RGBA
+		want lab
+	}{
+		{color.RGBA{0, 0, 0, 255}, lab{0, 0, 0}},
+		{color.RGBA{255, 255, 255, 255}, lab{100, 0, 0}},
+		{color.RGBA{255, 0, 0, 255}, lab{53.24, 80.09, 67.20}},
+		{color.RGBA{0, 0, 255, 255}, lab{32.30, 79.19, -107.86}},
+	}
+
+	for _, d := range testData {
+		t.Run(fmt.Sprint(d.c), func(t *testing.T) {
+			res := rgbToLab(d.c)
+			if math.Abs(res.L-d.want.L) > 0.05 || math.Abs(res.A-d.want.A) > 0.05 || math.Abs(res.B-d.want.B) > 0.05 {
+				t.Errorf("got %v, want %v", res, d.want)
+			}
+		})
+	}
+}
+
+func TestANSIForegroundPalettes(t *testing.T) {
+	testData := []struct {
+		c    color.RGBA
+		mode ColorMode
+		want string
+	}{
+		{color.RGBA{255, 0, 0, 255}, ANSI16, "\x1b[91m"},
+		{color.RGBA{200, 10, 5, 255}, ANSI16, "\x1b[31m"},
+		{color.RGBA{0, 0, 0, 255}, ANSI16, "\x1b[30m"},
+		{color.RGBA{255, 0, 0, 255}, ANSI256, "\x1b[38;5;196m"},
+		{color.RGBA{128, 128, 128, 255}, ANSI256, "\x1b[38;5;244m"},
+		{color.RGBA{95, 135, 175, 255}, ANSI256, "\x1b[38;5;67m"},
+	}
+
+	for _, d := range testData {
+		t.Run(fmt.Sprint(d.c, d.mode), func(t *testing.T) {
+			res := ansiForeground(d.c, d.mode)
+			if res != d.want {
+				t.Errorf("got %q, want %q", res, d.want)
+			}
+		})
+	}
+}
+
+func TestDitherColors(t *testing.T) {
+	// A flat mid-gray between two 16-color grays should dither into a mix of both
+	colors := image.NewRGBA(image.Rect(0, 0, 16, 16))
+	for y := range 16 {
+		for x := range 16 {
+			colors.SetRGBA(x, y, color.RGBA{178, 178, 178, 255})
+		}
+	}
+
+	res, err := DitherColors(colors, ANSI16)
+	if err != nil {
+		t.Fatalf("Failed to dither colors: %v", err)
+	}
+
+	seen := make(map[color.RGBA]int)
+	for y := range 16 {
+		for x := range 16 {
+			c := res.RGBAAt(x, y)
+			if ansi16.colors[ansi16.nearest(rgbToLab(c))] != c {
+				t.Fatalf("non-palette color %v at %d,%d", c, x, y)
+			}
+			seen[c]++
+		}
+	}
+	if len(seen) < 2 {
+		t.Errorf("got a single color %v, want a dithered mix", seen)
+	}
+
+	if _, err := DitherColors(colors, Truecolor); err == nil {
+		t.Errorf("expected error dithering in truecolor mode")
+	}
+}
@@ -0,0 +1,32 @@
+package asciiart
+
+import (
+	"runtime"
+	"sync"
+)
+
+// Split the rows from 0 to height into contiguous bands and call fn on each band from its own goroutine, one band
+// per worker (every CPU if workers is 0 or less), returning once all of them are done. fn must only write to the
+// rows it is given, so that the result is the same as running it serially.
+func parallelRows(height, workers int, fn func(y0, y1 int)) {
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	workers = min(workers, height)
+	if workers <= 1 {
+		fn(0, height)
+		return
+	}
+
+	var wg sync.WaitGroup
+	for i := range workers {
+		y0 := height * i / workers
+		y1 := height * (i + 1) / workers
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			fn(y0, y1)
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,39 @@
+package asciiart
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestParallelRows(t *testing.T) {
+	tests := []struct {
+		height  int
+		workers int
+	}{
+		{0, 4},
+		{1, 4},
+		{7, 1},
+		{7, 3},
+		{10, 4},
+		{3, 8},
+		{100, 0},
+		{100, -1},
+	}
+
+	for _, tt := range tests {
+		var mu sync.Mutex
+		seen := make([]int, tt.height)
+		parallelRows(tt.height, tt.workers, func(y0, y1 int) {
+			mu.Lock()
+			defer mu.Unlock()
+			for y := y0; y < y1; y++ {
+				seen[y]++
+			}
+		})
+		for y, n := range seen {
+			if n != 1 {
+				t.Errorf("height %d, %d workers: row %d covered %d times", tt.height, tt.workers, y, n)
+			}
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package asciiart
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"time"
+)
+
+const (
+	ansiHideCursor  = "\x1b[?25l"
+	ansiShowCursor  = "\x1b[?25h"
+	ansiClearScreen = "\x1b[2J"
+	ansiCursorHome  = "\x1b[H"
+	ansiClearToEnd  = "\x1b[J"
+)
+
+// Plays converted frames back in a terminal, redrawing each one over the last from the top left corner
+type Player struct {
+	Renderer     Renderer      // renders each frame, defaults to TextRenderer
+	Loops        int           // times to play through the frames, 0 to repeat forever
+	MaxFPS       float64       // cap on frames per second, 0 for no cap
+	DefaultDelay time.Duration // delay for frames that have none, defaults to 100ms
+}
+
+// Time to show a frame for, honoring the default delay and frame rate cap
+func (p Player) frameDelay(d time.Duration) time.Duration {
+	if d <= 0 {
+		d = p.DefaultDelay
+		if d <= 0 {
+			d = 100 * time.Millisecond
+		}
+	}
+	if p.MaxFPS > 0 {
+		d = max(d, time.Duration(float64(time.Second)/p.MaxFPS))
+	}
+	return d
+}
+
+// Terminal output redrawing each frame from the top left corner
+func (p Player) render(frames []ResultFrame) ([][]byte, error) {
+	if len(frames) == 0 {
+		return nil, fmt.Errorf("no frames to play")
+	}
+
+	renderer := p.Renderer
+	if renderer == nil {
+		renderer = TextRenderer{}
+	}
+
+	rendered := make([][]byte, len(frames))
+	for i, f := range frames {
+		var buf bytes.Buffer
+		buf.WriteString(ansiCursorHome)
+		err := renderer.Render(&buf, f.Result)
+		if err != nil {
+			return nil, err
+		}
+		buf.WriteString(ansiClearToEnd)
+		rendered[i] = buf.Bytes()
+	}
+	return rendered, nil
+}
+
+// Play frames to w until every loop has finished or ctx is canceled. The cursor is hidden during playback and
+// restored before returning, including on cancellation, in which case the context's error is returned.
+func (p Player) Play(ctx context.Context, w io.Writer, frames []ResultFrame) (err error) {
+	// Render everything up front so playback timing isn't thrown off by slow frames
+	rendered, err := p.render(frames)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.WriteString(w, ansiHideCursor+ansiClearScreen)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_, restoreErr := io.WriteString(w, ansiReset+ansiShowCursor)
+		if err == nil {
+			err = restoreErr
+		}
+	}()
+
+	for loop := 0; p.Loops <= 0 || loop < p.Loops; loop++ {
+		for i, f := range frames {
+			start := time.Now()
+			_, err = w.Write(rendered[i])
+			if err != nil {
+				return err
+			}
+
+			timer := time.NewTimer(p.frameDelay(f.Delay) - time.Since(start))
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return ctx.Err()
+			case <-timer.C:
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,65 @@
+package asciiart
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPlayerFrameDelay(t *testing.T) {
+	testData := []struct {
+		player Player
+		delay  time.Duration
+		want   time.Duration
+	}{
+		{Player{}, 0, 100 * time.Millisecond},
+		{Player{}, 40 * time.Millisecond, 40 * time.Millisecond},
+		{Player{DefaultDelay: time.Second}, 0, time.Second},
+		{Player{MaxFPS: 10}, 40 * time.Millisecond, 100 * time.Millisecond},
+		{Player{MaxFPS: 10}, 200 * time.Millisecond, 200 * time.Millisecond},
+	}
+
+	for _, d := range testData {
+		res := d.player.frameDelay(d.delay)
+		if res != d.want {
+			t.Errorf("%+v with delay %v: got %v, want %v", d.player, d.delay, res, d.want)
+		}
+	}
+}
+
+func TestPlayerPlay(t *testing.T) {
+	frames := []ResultFrame{
+		{Result: &Result{Art: [][]rune{[]rune("ab")}}, Delay: time.Millisecond},
+		{Result: &Result{Art: [][]rune{[]rune("cd")}}, Delay: time.Millisecond},
+	}
+
+	var buf bytes.Buffer
+	err := Player{Loops: 2}.Play(context.Background(), &buf, frames)
+	if err != nil {
+		t.Fatalf("Failed to play: %v", err)
+	}
+
+	frame := func(s string) string { return ansiCursorHome + s + "\n" + ansiClearToEnd }
+	want := ansiHideCursor + ansiClearScreen + strings.Repeat(frame("ab")+frame("cd"), 2) + ansiReset + ansiShowCursor
+	if buf.String() != want {
+		t.Errorf("got %q, want %q", buf.String(), want)
+	}
+}
+
+func TestPlayerCancel(t *testing.T) {
+	frames := []ResultFrame{{Result: &Result{Art: [][]rune{[]rune("ab")}}, Delay: time.Hour}}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	var buf bytes.Buffer
+	err := Player{}.Play(ctx, &buf, frames)
+	if err != context.DeadlineExceeded {
+		t.Errorf("got error %v, want %v", err, context.DeadlineExceeded)
+	}
+	if !strings.HasSuffix(buf.String(), ansiShowCursor) {
+		t.Errorf("cursor was not restored: %q", buf.String())
+	}
+}
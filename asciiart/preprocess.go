@@ -1,91 +1,251 @@
 package asciiart
 
 import (
+	"errors"
 	"fmt"
 	"image"
 	"image/color"
-	"log"
 	"math"
 
 	"github.com/disintegration/gift"
 )
 
+type DoGForm int
+
+const (
+	Sharpened  DoGForm = iota // (1 + τ) * G_1 - τ * G_2, an image sharpened by the difference
+	Difference                // G_1 - τ * G_2, the plain difference with τ just below 1
+)
+
+type ThresholdMode int
+
+const (
+	SoftThreshold      ThresholdMode = iota // fully bright above Epsilon, falling off smoothly below it at rate Phi
+	HardThreshold                           // fully bright above Epsilon, fully dark below it
+	QuantizedThreshold                      // soft threshold rounded to Levels evenly spaced gray levels
+)
+
 type DoGOptions struct {
-	Sigma1  float32 // Standard deviation of the first Gaussian blur
-	Sigma2  float32 // Standard deviation of the second Gaussian blur
-	Epsilon float32 // Threshold (0, 1) for Gaussian difference to be considered fully bright
-	Tau     float32 // Emphasis of larger-scale structures
-	Phi     float32 // Sharpness of edge transitions
+	Sigma1    float32       // Standard deviation of the first Gaussian blur
+	Sigma2    float32       // Standard deviation of the second Gaussian blur, larger than Sigma1
+	K         float32       // Ratio (above 1) of the second standard deviation to the first, used instead of Sigma2 when set
+	Epsilon   float32       // Threshold (0, 1) for Gaussian difference to be considered fully bright
+	Tau       float32       // Emphasis of larger-scale structures
+	Phi       float32       // Sharpness of edge transitions
+	Form      DoGForm       // Formulation combining the two Gaussians
+	Threshold ThresholdMode // Mapping of the combined Gaussians to output intensity
+	Levels    int           // Number of gray levels (at least 2) for QuantizedThreshold
+	Invert    bool          // Whether to invert the output, giving light lines on a dark background
+}
+
+// Look up a DoG formulation by name as accepted by the CLI
+func ParseDoGForm(name string) (DoGForm, error) {
+	switch name {
+	case "", "sharpened":
+		return Sharpened, nil
+	case "difference":
+		return Difference, nil
+	default:
+		return Sharpened, fmt.Errorf("unknown dog form: %q", name)
+	}
+}
+
+// Look up a DoG threshold mode by name as accepted by the CLI
+func ParseThresholdMode(name string) (ThresholdMode, error) {
+	switch name {
+	case "", "soft":
+		return SoftThreshold, nil
+	case "hard":
+		return HardThreshold, nil
+	case "quantized":
+		return QuantizedThreshold, nil
+	default:
+		return SoftThreshold, fmt.Errorf("unknown threshold mode: %q", name)
+	}
 }
 
 func validateDoGOptions(opts DoGOptions) error {
+	var errs []error
+	if opts.Sigma1 <= 0 {
+		errs = append(errs, optionError("DoGOptions.Sigma1", opts.Sigma1, "must be positive"))
+	}
+	if opts.K != 0 {
+		if opts.K <= 1 {
+			errs = append(errs, optionError("DoGOptions.K", opts.K, "must be greater than 1"))
+		}
+	} else if opts.Sigma2 <= opts.Sigma1 {
+		errs = append(errs, optionError("DoGOptions.Sigma2", opts.Sigma2, fmt.Sprintf("must be greater than sigma1 (%v)", opts.Sigma1)))
+	}
+
 	if opts.Epsilon > 1 || opts.Epsilon < 0 {
-		return fmt.Errorf("epsilon must be between 0 and 1, inclusive")
+		errs = append(errs, optionError("DoGOptions.Epsilon", opts.Epsilon, "must be between 0 and 1, inclusive"))
+	}
+
+	switch opts.Form {
+	case Sharpened:
+		if opts.Tau < 0 {
+			errs = append(errs, optionError("DoGOptions.Tau", opts.Tau, "must be non-negative"))
+		}
+	case Difference:
+		if opts.Tau < 0 || opts.Tau > 1 {
+			errs = append(errs, optionError("DoGOptions.Tau", opts.Tau, "must be between 0 and 1, inclusive, for the difference form"))
+		}
+	default:
+		errs = append(errs, optionError("DoGOptions.Form", opts.Form, "unknown dog form"))
+	}
+
+	switch opts.Threshold {
+	case SoftThreshold, HardThreshold, QuantizedThreshold:
+	default:
+		errs = append(errs, optionError("DoGOptions.Threshold", opts.Threshold, "unknown threshold mode"))
+	}
+	if opts.Threshold == QuantizedThreshold && opts.Levels < 2 {
+		errs = append(errs, optionError("DoGOptions.Levels", opts.Levels, "must be at least 2"))
+	}
+	// Phi only shapes the soft falloff
+	if opts.Threshold != HardThreshold && opts.Phi <= 0 {
+		errs = append(errs, optionError("DoGOptions.Phi", opts.Phi, "must be positive"))
+	}
+
+	return errors.Join(errs...)
+}
+
+// Standard deviation of the second Gaussian blur
+func (opts DoGOptions) sigma2() float32 {
+	if opts.K != 0 {
+		return opts.K * opts.Sigma1
 	}
+	return opts.Sigma2
+}
 
-	return nil
+// Weights of the two Gaussians in the combined operator
+func (opts DoGOptions) weights() (float32, float32) {
+	if opts.Form == Difference {
+		return 1, opts.Tau
+	}
+	return 1 + opts.Tau, opts.Tau
 }
 
-// Extended thresholding function for DoG output
-func tanThreshold(u, epsilon, phi float32) (float32, error) {
-	if epsilon > 1 || epsilon < 0 {
-		return 0, fmt.Errorf("epsilon must be between 0 and 1, inclusive")
+// Map the combined Gaussians u to an output intensity from 0 to 1
+func (opts DoGOptions) threshold(u float32) float32 {
+	var d float32
+	switch {
+	case u >= opts.Epsilon:
+		d = 1
+	case opts.Threshold == HardThreshold:
+		d = 0
+	default:
+		// Extended thresholding function: 1 + tanh falls from 1 at ε toward 0, clamped against rounding
+		d = float32(1 + math.Tanh(float64(opts.Phi*(u-opts.Epsilon))))
+		d = min(max(d, 0), 1)
+		if opts.Threshold == QuantizedThreshold {
+			steps := float32(opts.Levels - 1)
+			d = float32(math.Round(float64(d*steps))) / steps
+		}
 	}
-	if u >= epsilon {
-		return 1, nil
+
+	if opts.Invert {
+		d = 1 - d
 	}
-	return float32((1 + math.Tanh(float64(phi*(u-epsilon))))), nil
+	return d
 }
 
 // Apply Difference of Gaussians as preprocessor for edge detection
 func DoG(img image.Image, opts DoGOptions) (*image.Gray, error) {
+	return doG(img, opts, 0, nil)
+}
+
+func doG(img image.Image, opts DoGOptions, workers int, s *stage) (*image.Gray, error) {
 	err := validateDoGOptions(opts)
 	if err != nil {
 		return nil, err
 	}
 
-	log.Println("Applying Difference of Gaussians preprocessing...")
 	b1 := gift.New(gift.GaussianBlur(opts.Sigma1))
-	b2 := gift.New(gift.GaussianBlur(opts.Sigma2))
+	b2 := gift.New(gift.GaussianBlur(opts.sigma2()))
+	w1, w2 := opts.weights()
 
 	dst1 := image.NewGray(b1.Bounds(img.Bounds()))
 	b1.Draw(dst1, img)
 
+	if err := s.err(); err != nil {
+		return nil, err
+	}
 	dst2 := image.NewGray(b2.Bounds(img.Bounds()))
 	b2.Draw(dst2, img)
+	if err := s.err(); err != nil {
+		return nil, err
+	}
 
 	width := img.Bounds().Dx()
 	height := img.Bounds().Dy()
 
 	doG := image.NewGray(img.Bounds())
-	for i := range height {
+	err = s.rows(height, workers, func(i int) {
+		row1 := dst1.Pix[i*dst1.Stride:]
+		row2 := dst2.Pix[i*dst2.Stride:]
+		dst := doG.Pix[i*doG.Stride:]
 		for j := range width {
-
-			p1 := dst1.GrayAt(j, i)
-			p2 := dst2.GrayAt(j, i)
-
-			// Winnemoller's XDoG operator: (1 + τ) * G_1 - τ * G_2
-			g1 := float32(p1.Y) / 255
-			g2 := float32(p2.Y) / 255
-			d, err := tanThreshold((1+opts.Tau)*g1-opts.Tau*g2, opts.Epsilon, opts.Phi)
-			if err != nil {
-				return nil, err
-			}
-			doG.Set(j, i, color.Gray{Y: uint8(math.Round(255 * float64(d)))})
+			// Winnemoller's XDoG operator, e.g. (1 + τ) * G_1 - τ * G_2
+			g1 := float32(row1[j]) / 255
+			g2 := float32(row2[j]) / 255
+			d := opts.threshold(w1*g1 - w2*g2)
+			dst[j] = uint8(math.Round(255 * float64(d)))
 		}
+	})
+	if err != nil {
+		return nil, err
 	}
 	return doG, nil
 }
 
 func Grayscale(img image.Image) *image.Gray {
+	return grayscale(img, 0)
+}
+
+func grayscale(img image.Image, workers int) *image.Gray {
 	bounds := img.Bounds()
 	dst := image.NewGray(bounds)
-	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
-		for x := bounds.Min.X; x < bounds.Max.X; x++ {
-			oldColor := img.At(x, y)
-			grayColor := color.GrayModel.Convert(oldColor)
-			dst.Set(x, y, grayColor)
-		}
+	width := bounds.Dx()
+
+	// Read the pixels of common image types directly, converting with the same formula as color.GrayModel
+	luma := func(r, g, b uint32) uint8 {
+		return uint8((19595*r + 38470*g + 7471*b + 1<<15) >> 24)
 	}
+
+	parallelRows(bounds.Dy(), workers, func(y0, y1 int) {
+		for y := y0; y < y1; y++ {
+			row := dst.Pix[y*dst.Stride : y*dst.Stride+width]
+			switch src := img.(type) {
+			case *image.Gray:
+				copy(row, src.Pix[y*src.Stride:])
+			case *image.RGBA:
+				pix := src.Pix[y*src.Stride:]
+				for x := range row {
+					p := pix[4*x : 4*x+3]
+					row[x] = luma(uint32(p[0])*0x101, uint32(p[1])*0x101, uint32(p[2])*0x101)
+				}
+			case *image.NRGBA:
+				pix := src.Pix[y*src.Stride:]
+				for x := range row {
+					p := pix[4*x : 4*x+4]
+					r, g, b, _ := color.NRGBA{R: p[0], G: p[1], B: p[2], A: p[3]}.RGBA()
+					row[x] = luma(r, g, b)
+				}
+			case *image.YCbCr:
+				// Luma alone rounds differently from GrayModel, which goes through RGB, so convert with the chroma
+				for x := range row {
+					yi := src.YOffset(bounds.Min.X+x, bounds.Min.Y+y)
+					ci := src.COffset(bounds.Min.X+x, bounds.Min.Y+y)
+					r, g, b, _ := color.YCbCr{Y: src.Y[yi], Cb: src.Cb[ci], Cr: src.Cr[ci]}.RGBA()
+					row[x] = luma(r, g, b)
+				}
+			default:
+				for x := range row {
+					row[x] = color.GrayModel.Convert(img.At(bounds.Min.X+x, bounds.Min.Y+y)).(color.Gray).Y
+				}
+			}
+		}
+	})
 	return dst
 }
@@ -1,14 +1,54 @@
 package asciiart
 
 import (
+	"bytes"
 	"image"
+	"image/color"
 	"image/png"
+	"math"
 	"os"
 	"path/filepath"
 	"strconv"
 	"testing"
 )
 
+func TestGrayscale(t *testing.T) {
+	bounds := image.Rect(3, 5, 40, 30)
+	rgba := image.NewRGBA(bounds)
+	nrgba := image.NewNRGBA(bounds)
+	ycbcr := image.NewYCbCr(bounds, image.YCbCrSubsampleRatio420)
+	paletted := image.NewPaletted(bounds, color.Palette{color.Black, color.White, color.RGBA{200, 30, 90, 255}})
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			c := color.NRGBA{R: uint8(x * 7), G: uint8(y * 11), B: uint8(x * y), A: uint8(128 + x)}
+			rgba.Set(x, y, c)
+			nrgba.Set(x, y, c)
+			paletted.SetColorIndex(x, y, uint8((x+y)%3))
+			ycbcr.Y[ycbcr.YOffset(x, y)] = uint8(x*y + 13)
+			ycbcr.Cb[ycbcr.COffset(x, y)] = uint8(x * 9)
+			ycbcr.Cr[ycbcr.COffset(x, y)] = uint8(255 - y*5)
+		}
+	}
+
+	images := map[string]image.Image{"rgba": rgba, "nrgba": nrgba, "ycbcr": ycbcr, "paletted": paletted}
+	for name, img := range images {
+		t.Run(name, func(t *testing.T) {
+			gray := grayscale(img, 4)
+			if gray.Bounds() != bounds {
+				t.Fatalf("got bounds %v, want %v", gray.Bounds(), bounds)
+			}
+			for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+				for x := bounds.Min.X; x < bounds.Max.X; x++ {
+					want := color.GrayModel.Convert(img.At(x, y)).(color.Gray).Y
+					if got := gray.GrayAt(x, y).Y; got != want {
+						t.Fatalf("pixel %d,%d: got %d, want %d", x, y, got, want)
+					}
+				}
+			}
+		})
+	}
+}
+
 func TestDoG(t *testing.T) {
 	testData := []struct {
 		filePath string
@@ -66,3 +106,91 @@ func TestDoG(t *testing.T) {
 		t.Logf("Image saved as TestDoG%d.png", i)
 	}
 }
+
+func TestValidateDoGOptions(t *testing.T) {
+	valid := DoGOptions{Sigma1: 4, Sigma2: 10, Epsilon: 0.65, Tau: 0.8, Phi: 25, Levels: 4}
+
+	testData := []struct {
+		name    string
+		modify  func(o *DoGOptions)
+		wantErr bool
+	}{
+		{"defaults", func(o *DoGOptions) {}, false},
+		{"zero sigma1", func(o *DoGOptions) { o.Sigma1 = 0 }, true},
+		{"sigma2 below sigma1", func(o *DoGOptions) { o.Sigma2 = 3 }, true},
+		{"k instead of sigma2", func(o *DoGOptions) { o.Sigma2, o.K = 0, 1.6 }, false},
+		{"k of 1", func(o *DoGOptions) { o.K = 1 }, true},
+		{"epsilon above 1", func(o *DoGOptions) { o.Epsilon = 1.5 }, true},
+		{"negative tau", func(o *DoGOptions) { o.Tau = -1 }, true},
+		{"sharpening tau above 1", func(o *DoGOptions) { o.Tau = 20 }, false},
+		{"difference tau above 1", func(o *DoGOptions) { o.Tau, o.Form = 20, Difference }, true},
+		{"unknown form", func(o *DoGOptions) { o.Form = 7 }, true},
+		{"zero phi", func(o *DoGOptions) { o.Phi = 0 }, true},
+		{"zero phi with hard threshold", func(o *DoGOptions) { o.Phi, o.Threshold = 0, HardThreshold }, false},
+		{"one level", func(o *DoGOptions) { o.Levels, o.Threshold = 1, QuantizedThreshold }, true},
+		{"unknown threshold", func(o *DoGOptions) { o.Threshold = 7 }, true},
+	}
+
+	for _, d := range testData {
+		t.Run(d.name, func(t *testing.T) {
+			opts := valid
+			d.modify(&opts)
+			err := validateDoGOptions(opts)
+			if (err != nil) != d.wantErr {
+				t.Errorf("got error %v, want error %t", err, d.wantErr)
+			}
+		})
+	}
+}
+
+func TestDoGThreshold(t *testing.T) {
+	soft := DoGOptions{Epsilon: 0.5, Phi: 10, Levels: 3}
+	hard := soft
+	hard.Threshold = HardThreshold
+	quantized := soft
+	quantized.Threshold = QuantizedThreshold
+	inverted := soft
+	inverted.Invert = true
+
+	testData := []struct {
+		name string
+		opts DoGOptions
+		u    float32
+		want float32
+	}{
+		{"soft above epsilon", soft, 0.6, 1},
+		{"soft at epsilon", soft, 0.5, 1},
+		{"soft below epsilon", soft, 0.45, float32(1 + math.Tanh(-0.5))},
+		{"soft far below epsilon", soft, -100, 0},
+		{"hard above epsilon", hard, 0.6, 1},
+		{"hard below epsilon", hard, 0.45, 0},
+		{"quantized below epsilon", quantized, 0.45, 0.5},
+		{"quantized far below epsilon", quantized, 0.2, 0},
+		{"inverted", inverted, 0.6, 0},
+	}
+
+	for _, d := range testData {
+		t.Run(d.name, func(t *testing.T) {
+			res := d.opts.threshold(d.u)
+			if math.Abs(float64(res-d.want)) > 1e-6 {
+				t.Errorf("got %f, want %f", res, d.want)
+			}
+		})
+	}
+}
+
+func TestDoGKRatio(t *testing.T) {
+	img := grayImage(40, 40, func(x, y int) uint8 { return uint8(255 * min(max(x-19, 0), 1)) })
+
+	explicit, err := DoG(img, DoGOptions{Sigma1: 2, Sigma2: 5, Epsilon: 0.65, Tau: 0.8, Phi: 25})
+	if err != nil {
+		t.Fatalf("Failed to perform Difference of Gaussians: %v", err)
+	}
+	ratio, err := DoG(img, DoGOptions{Sigma1: 2, K: 2.5, Epsilon: 0.65, Tau: 0.8, Phi: 25})
+	if err != nil {
+		t.Fatalf("Failed to perform Difference of Gaussians: %v", err)
+	}
+	if !bytes.Equal(explicit.Pix, ratio.Pix) {
+		t.Error("k ratio of 2.5 differs from explicit sigma2 of 5")
+	}
+}
@@ -0,0 +1,101 @@
+package asciiart
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Logger for conversions that don't set one, dropping every record
+var discardLogger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+// Cancellation, progress reporting and logging for one stage of a conversion, shared by the goroutines working on its
+// rows. A nil *stage is never canceled and reports nothing, for the functions called outside of a conversion.
+type stage struct {
+	ctx      context.Context
+	name     string
+	progress func(stage string, fraction float64)
+	logger   *slog.Logger
+	start    time.Time
+
+	mu       sync.Mutex
+	total    int
+	done     int
+	reported int // hundredths last reported, so the callback isn't flooded a row at a time
+}
+
+// Start the named stage, reporting it at 0 unless ctx is already done. A nil logger logs nothing.
+func newStage(ctx context.Context, name string, progress func(stage string, fraction float64), logger *slog.Logger) (*stage, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if logger == nil {
+		logger = discardLogger
+	}
+	s := &stage{ctx: ctx, name: name, progress: progress, logger: logger, start: time.Now()}
+	logger.Debug("starting stage", "stage", name)
+	if progress != nil {
+		progress(name, 0)
+	}
+	return s, nil
+}
+
+// The context's error once it is done
+func (s *stage) err() error {
+	if s == nil {
+		return nil
+	}
+	return s.ctx.Err()
+}
+
+// Like parallelRows, calling fn a row at a time, reporting the fraction of rows done as they finish and stopping
+// early once the context is done, whose error is returned
+func (s *stage) rows(height, workers int, fn func(y int)) error {
+	if s == nil {
+		parallelRows(height, workers, func(y0, y1 int) {
+			for y := y0; y < y1; y++ {
+				fn(y)
+			}
+		})
+		return nil
+	}
+
+	s.mu.Lock()
+	s.total, s.done, s.reported = height, 0, 0
+	s.mu.Unlock()
+
+	parallelRows(height, workers, func(y0, y1 int) {
+		for y := y0; y < y1; y++ {
+			if s.ctx.Err() != nil {
+				return
+			}
+			fn(y)
+			s.rowDone()
+		}
+	})
+	return s.ctx.Err()
+}
+
+func (s *stage) rowDone() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.done++
+	hundredths := 100 * s.done / s.total
+	if s.progress != nil && hundredths > s.reported && hundredths < 100 && s.ctx.Err() == nil {
+		s.reported = hundredths
+		s.progress(s.name, float64(s.done)/float64(s.total))
+	}
+}
+
+// Report the stage as complete, logging how long it took along with attrs
+func (s *stage) finish(attrs ...any) {
+	if s == nil {
+		return
+	}
+	s.logger.Info("finished stage", append([]any{"stage", s.name, "duration", time.Since(s.start)}, attrs...)...)
+	if s.progress != nil {
+		s.progress(s.name, 1)
+	}
+}
@@ -0,0 +1,87 @@
+package asciiart
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestStageRows(t *testing.T) {
+	var fractions []float64
+	s, err := newStage(context.Background(), "test", func(stage string, fraction float64) {
+		if stage != "test" {
+			t.Errorf("got stage %q, want %q", stage, "test")
+		}
+		fractions = append(fractions, fraction)
+	}, nil)
+	if err != nil {
+		t.Fatalf("Error starting stage: %v", err)
+	}
+
+	rows := make([]int, 500)
+	err = s.rows(len(rows), 4, func(y int) {
+		rows[y]++
+	})
+	if err != nil {
+		t.Fatalf("Error running rows: %v", err)
+	}
+	s.finish()
+
+	for y, n := range rows {
+		if n != 1 {
+			t.Errorf("row %d ran %d times", y, n)
+		}
+	}
+	if len(fractions) < 3 || len(fractions) > 101 {
+		t.Errorf("got %d progress reports for 500 rows", len(fractions))
+	}
+	if fractions[0] != 0 || fractions[len(fractions)-1] != 1 {
+		t.Errorf("progress should run from 0 to 1: %v", fractions)
+	}
+	for i := 1; i < len(fractions); i++ {
+		if fractions[i] <= fractions[i-1] {
+			t.Errorf("progress went from %v to %v", fractions[i-1], fractions[i])
+		}
+	}
+}
+
+func TestStageRowsCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	s, err := newStage(ctx, "test", nil, nil)
+	if err != nil {
+		t.Fatalf("Error starting stage: %v", err)
+	}
+
+	ran := 0
+	err = s.rows(100, 1, func(y int) {
+		ran++
+		if y == 9 {
+			cancel()
+		}
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("got error %v, want %v", err, context.Canceled)
+	}
+	if ran != 10 {
+		t.Errorf("ran %d rows after canceling at the tenth", ran)
+	}
+
+	_, err = newStage(ctx, "next", nil, nil)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("starting a stage after cancellation: got error %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestNilStage(t *testing.T) {
+	var s *stage
+	ran := 0
+	err := s.rows(10, 1, func(int) { ran++ })
+	if err != nil || s.err() != nil {
+		t.Errorf("nil stage returned an error: %v", err)
+	}
+	if ran != 10 {
+		t.Errorf("nil stage ran %d of 10 rows", ran)
+	}
+	s.finish()
+}
@@ -0,0 +1,121 @@
+package asciiart
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"math"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+// Draws the characters into an image with a bitmap font and encodes it as PNG or JPEG. Cells are as wide as the
+// font's advance and Squash times as tall, so the output keeps the aspect ratio of the source image.
+type ImageRenderer struct {
+	Format     string      // "png" (default) or "jpeg"
+	Quality    int         // JPEG quality (1 to 100), defaults to 90
+	Face       font.Face   // monospace font to draw with, defaults to the 7x13 X11 fixed font
+	Background color.Color // fill behind the text, defaults to black
+	Foreground color.Color // text color when not coloring cells, defaults to white
+	Color      bool        // draw each character in its cell's color from the result
+}
+
+func (ir ImageRenderer) withDefaults() ImageRenderer {
+	if ir.Format == "" {
+		ir.Format = "png"
+	}
+	if ir.Quality == 0 {
+		ir.Quality = 90
+	}
+	if ir.Face == nil {
+		ir.Face = basicfont.Face7x13
+	}
+	if ir.Background == nil {
+		ir.Background = color.Black
+	}
+	if ir.Foreground == nil {
+		ir.Foreground = color.White
+	}
+	return ir
+}
+
+// Size in pixels of one character cell
+func (ir ImageRenderer) cellSize(r *Result) (int, int) {
+	ir = ir.withDefaults()
+	advance, ok := ir.Face.GlyphAdvance('M')
+	if !ok {
+		advance = ir.Face.Metrics().Height
+	}
+	width := advance.Ceil()
+
+	// Results built by hand carry no geometry; fall back to the font's own line height
+	if r.XScale <= 0 || r.YScale <= 0 {
+		return width, ir.Face.Metrics().Height.Ceil()
+	}
+	return width, max(1, int(math.Round(float64(width)*r.YScale/r.XScale)))
+}
+
+// Draws the characters of r into a new image
+func (ir ImageRenderer) Rasterize(r *Result) (*image.RGBA, error) {
+	if ir.Color {
+		if _, err := resultColors(r); err != nil {
+			return nil, err
+		}
+	}
+	ir = ir.withDefaults()
+
+	cellW, cellH := ir.cellSize(r)
+	dst := image.NewRGBA(image.Rect(0, 0, r.Width()*cellW, r.Height()*cellH))
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(ir.Background), image.Point{}, draw.Src)
+
+	// Center the font's line box vertically in the cell
+	metrics := ir.Face.Metrics()
+	baseline := (cellH-(metrics.Ascent+metrics.Descent).Ceil())/2 + metrics.Ascent.Ceil()
+
+	fg := image.NewUniform(ir.Foreground)
+	d := &font.Drawer{Src: fg, Face: ir.Face}
+	for y, row := range r.Art {
+		for x, char := range row {
+			if char == ' ' {
+				continue
+			}
+			if ir.Color {
+				fg.C = cellColor(r, x, y)
+			}
+
+			// Drawing into the cell's subimage clips glyphs taller than the cell
+			cell := image.Rect(x*cellW, y*cellH, (x+1)*cellW, (y+1)*cellH)
+			d.Dst = dst.SubImage(cell).(*image.RGBA)
+			d.Dot = fixed.P(cell.Min.X, cell.Min.Y+baseline)
+			d.DrawString(string(char))
+		}
+	}
+
+	return dst, nil
+}
+
+func (ir ImageRenderer) Render(w io.Writer, r *Result) error {
+	img, err := ir.Rasterize(r)
+	if err != nil {
+		return err
+	}
+
+	ir = ir.withDefaults()
+	switch ir.Format {
+	case "png":
+		return png.Encode(w, img)
+	case "jpeg", "jpg":
+		if ir.Quality < 1 || ir.Quality > 100 {
+			return fmt.Errorf("jpeg quality must be between 1 and 100, inclusive: %d", ir.Quality)
+		}
+		return jpeg.Encode(w, img, &jpeg.Options{Quality: ir.Quality})
+	default:
+		return fmt.Errorf("unknown image format: %q", ir.Format)
+	}
+}
@@ -0,0 +1,82 @@
+package asciiart
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+)
+
+func TestImageRendererRasterize(t *testing.T) {
+	r := &Result{Art: [][]rune{[]rune("@ "), []rune(" @")}, XScale: 4, YScale: 8}
+
+	img, err := ImageRenderer{}.Rasterize(r)
+	if err != nil {
+		t.Fatalf("Failed to rasterize: %v", err)
+	}
+
+	// 7 pixel wide cells, twice as tall to honor the 2x squash
+	if img.Bounds() != image.Rect(0, 0, 14, 28) {
+		t.Fatalf("got bounds %v, want %v", img.Bounds(), image.Rect(0, 0, 14, 28))
+	}
+
+	lit := func(cell image.Rectangle) int {
+		n := 0
+		for y := cell.Min.Y; y < cell.Max.Y; y++ {
+			for x := cell.Min.X; x < cell.Max.X; x++ {
+				if img.RGBAAt(x, y) != (color.RGBA{0, 0, 0, 255}) {
+					n++
+				}
+			}
+		}
+		return n
+	}
+
+	if n := lit(image.Rect(0, 0, 7, 14)); n == 0 {
+		t.Errorf("cell 0,0 has no glyph pixels")
+	}
+	if n := lit(image.Rect(7, 0, 14, 14)); n != 0 {
+		t.Errorf("blank cell 1,0 has %d glyph pixels", n)
+	}
+	if n := lit(image.Rect(0, 14, 7, 28)); n != 0 {
+		t.Errorf("blank cell 0,1 has %d glyph pixels", n)
+	}
+	if n := lit(image.Rect(7, 14, 14, 28)); n == 0 {
+		t.Errorf("cell 1,1 has no glyph pixels")
+	}
+}
+
+func TestImageRendererColor(t *testing.T) {
+	colors := image.NewRGBA(image.Rect(0, 0, 1, 1))
+	colors.SetRGBA(0, 0, color.RGBA{255, 0, 0, 255})
+	r := &Result{Art: [][]rune{[]rune("#")}, Colors: colors}
+
+	var buf bytes.Buffer
+	err := ImageRenderer{Color: true, Background: color.White}.Render(&buf, r)
+	if err != nil {
+		t.Fatalf("Failed to render: %v", err)
+	}
+
+	img, err := png.Decode(&buf)
+	if err != nil {
+		t.Fatalf("Failed to decode rendered png: %v", err)
+	}
+
+	red := false
+	bounds := img.Bounds()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			if color.RGBAModel.Convert(img.At(x, y)) == (color.RGBA{255, 0, 0, 255}) {
+				red = true
+			}
+		}
+	}
+	if !red {
+		t.Errorf("glyph was not drawn in its cell color")
+	}
+
+	if err := (ImageRenderer{Format: "bmp"}).Render(&buf, r); err == nil {
+		t.Errorf("expected error rendering an unknown format")
+	}
+}
@@ -0,0 +1,61 @@
+package asciiart
+
+import (
+	"bufio"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+)
+
+// Renderer writes a conversion result to w in some output format
+type Renderer interface {
+	Render(w io.Writer, r *Result) error
+}
+
+// Renders the characters as plain text, one line per row
+type TextRenderer struct{}
+
+func (TextRenderer) Render(w io.Writer, r *Result) error {
+	bw := bufio.NewWriter(w)
+	for _, row := range r.Art {
+		for _, char := range row {
+			bw.WriteRune(char)
+		}
+		bw.WriteByte('\n')
+	}
+	return bw.Flush()
+}
+
+// Cell colors of a result, checked to cover its whole grid
+func resultColors(r *Result) (*image.RGBA, error) {
+	if r.Colors == nil {
+		return nil, fmt.Errorf("result has no colors; convert with DoColor enabled")
+	}
+	if r.Colors.Rect.Dx() < r.Width() || r.Colors.Rect.Dy() < r.Height() {
+		return nil, fmt.Errorf("%w: %d x %d art and %d x %d colors", ErrMismatchedDimensions, r.Width(), r.Height(), r.Colors.Rect.Dx(), r.Colors.Rect.Dy())
+	}
+	return r.Colors, nil
+}
+
+// Color of the cell at x, y of a result with colors
+func cellColor(r *Result, x, y int) color.RGBA {
+	return r.Colors.RGBAAt(r.Colors.Rect.Min.X+x, r.Colors.Rect.Min.Y+y)
+}
+
+// Calls fn for each run of consecutive same-colored cells in row y, with the run's [start, end) columns
+func colorRuns(r *Result, y int, fn func(start, end int, c color.RGBA)) {
+	width := len(r.Art[y])
+	start := 0
+	for x := 1; x <= width; x++ {
+		if x < width && cellColor(r, x, y) == cellColor(r, start, y) {
+			continue
+		}
+		fn(start, x, cellColor(r, start, y))
+		start = x
+	}
+}
+
+func hexColor(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
@@ -0,0 +1,40 @@
+package asciiart
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"io"
+	"testing"
+)
+
+func TestTextRenderer(t *testing.T) {
+	r := &Result{Art: [][]rune{[]rune("_/|\\"), []rune(" .:@")}}
+
+	var buf bytes.Buffer
+	err := TextRenderer{}.Render(&buf, r)
+	if err != nil {
+		t.Fatalf("Failed to render: %v", err)
+	}
+
+	want := "_/|\\\n .:@\n"
+	if buf.String() != want {
+		t.Errorf("got %q, want %q", buf.String(), want)
+	}
+}
+
+func TestRenderMismatchedColors(t *testing.T) {
+	r := &Result{Art: [][]rune{[]rune("abc"), []rune("def")}, Colors: image.NewRGBA(image.Rect(0, 0, 3, 1))}
+
+	renderers := map[string]Renderer{
+		"ansi":  ANSIRenderer{Mode: Truecolor},
+		"html":  HTMLRenderer{Color: true},
+		"svg":   SVGRenderer{Color: true},
+		"image": ImageRenderer{Color: true},
+	}
+	for name, renderer := range renderers {
+		if err := renderer.Render(io.Discard, r); !errors.Is(err, ErrMismatchedDimensions) {
+			t.Errorf("%s: got error %v, want %v", name, err, ErrMismatchedDimensions)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package asciiart
+
+import (
+	"image"
+	"math"
+)
+
+// Output of Converter.Convert: the final glyph grid plus the per-cell data it was built from. All grids share the
+// same dimensions, indexed [y][x] for slices and by cell coordinates for images.
+type Result struct {
+	Art       [][]rune    // final ascii characters
+	Luminance *image.Gray // average luminance of each cell
+	Edges     [][]Edge    // dominant edge direction of each cell, nil without edge detection
+	Density   [][]float32 // fraction of each cell's pixels sharing its dominant edge, nil without edge detection
+	Overlaid  [][]bool    // whether the base character was replaced by an edge, nil unless both passes ran
+	Colors    *image.RGBA // average color of each cell, nil unless DoColor is set
+	Source    image.Rectangle
+	XScale    float64 // source pixels per cell horizontally
+	YScale    float64 // source pixels per cell vertically
+}
+
+func newResult(src image.Rectangle, width int, squash float32) *Result {
+	xScale := float64(src.Dx()) / float64(width)
+	return &Result{
+		Source: src,
+		XScale: xScale,
+		YScale: xScale * float64(squash),
+	}
+}
+
+// Width of the result in cells
+func (r *Result) Width() int {
+	if len(r.Art) == 0 {
+		return 0
+	}
+	return len(r.Art[0])
+}
+
+// Height of the result in cells
+func (r *Result) Height() int {
+	return len(r.Art)
+}
+
+// Rectangle of source pixels covered by the cell at x, y
+func (r *Result) Bounds(x, y int) image.Rectangle {
+	minX := r.Source.Min.X + int(math.Floor(float64(x)*r.XScale))
+	minY := r.Source.Min.Y + int(math.Floor(float64(y)*r.YScale))
+	rect := image.Rect(minX, minY, minX+int(math.Ceil(r.XScale)), minY+int(math.Ceil(r.YScale)))
+	return rect.Intersect(r.Source)
+}
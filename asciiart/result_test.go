@@ -0,0 +1,30 @@
+package asciiart
+
+import (
+	"fmt"
+	"image"
+	"testing"
+)
+
+func TestResultBounds(t *testing.T) {
+	r := newResult(image.Rect(10, 20, 110, 220), 40, 2)
+
+	testData := []struct {
+		x, y int
+		want image.Rectangle
+	}{
+		{0, 0, image.Rect(10, 20, 13, 25)},
+		{1, 0, image.Rect(12, 20, 15, 25)},
+		{0, 1, image.Rect(10, 25, 13, 30)},
+		{39, 39, image.Rect(107, 215, 110, 220)},
+	}
+
+	for _, d := range testData {
+		t.Run(fmt.Sprintf("%d,%d", d.x, d.y), func(t *testing.T) {
+			res := r.Bounds(d.x, d.y)
+			if res != d.want {
+				t.Errorf("got %v, want %v", res, d.want)
+			}
+		})
+	}
+}
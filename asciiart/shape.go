@@ -0,0 +1,267 @@
+package asciiart
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"math"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+type ShapeMetric int
+
+const (
+	L2   ShapeMetric = iota // squared differences of tone and of pattern, matching brightness as well as shape
+	SSIM                    // structural similarity, matching the pattern of light and dark more than its level
+)
+
+type ShapeOptions struct {
+	Metric     ShapeMetric // distance between a cell's samples and a glyph's bitmap
+	Cols, Rows int         // sub-cell grid each cell and glyph is sampled at
+	Face       font.Face   // monospace font the glyphs are rendered with, defaults to the 7x13 X11 fixed font
+}
+
+var shapeMetricNames = map[ShapeMetric]string{
+	L2:   "l2",
+	SSIM: "ssim",
+}
+
+func (m ShapeMetric) String() string {
+	if name, ok := shapeMetricNames[m]; ok {
+		return name
+	}
+	return fmt.Sprintf("ShapeMetric(%d)", int(m))
+}
+
+// Look up a shape metric by name as accepted by the CLI
+func ParseShapeMetric(name string) (ShapeMetric, error) {
+	if name == "" {
+		return L2, nil
+	}
+	for m, n := range shapeMetricNames {
+		if n == name {
+			return m, nil
+		}
+	}
+	return L2, fmt.Errorf("unknown shape metric: %q", name)
+}
+
+func validateShapeOptions(opts ShapeOptions) error {
+	var errs []error
+	if opts.Metric != L2 && opts.Metric != SSIM {
+		errs = append(errs, optionError("ShapeOptions.Metric", opts.Metric, "unknown shape metric"))
+	}
+	if opts.Cols <= 0 {
+		errs = append(errs, optionError("ShapeOptions.Cols", opts.Cols, "must be positive"))
+	}
+	if opts.Rows <= 0 {
+		errs = append(errs, optionError("ShapeOptions.Rows", opts.Rows, "must be positive"))
+	}
+	return errors.Join(errs...)
+}
+
+// Glyphs of a charset rendered and sampled down to a grid of Cols x Rows sub-cells
+type GlyphAtlas struct {
+	Cols, Rows int
+	Glyphs     []rune
+	Bitmaps    [][]float32 // ink coverage (0 to 1) of each glyph's sub-cells, row-major
+}
+
+// Render every glyph of charset with face (the 7x13 fixed font if nil) into a cell as wide as the font's advance and
+// as tall as its line, and average the coverage over a cols x rows grid. Glyphs the font lacks come out blank.
+func NewGlyphAtlas(face font.Face, charset []rune, cols, rows int) (*GlyphAtlas, error) {
+	if len(charset) == 0 {
+		return nil, optionError("charset", string(charset), "must not be empty")
+	}
+	if cols <= 0 || rows <= 0 {
+		return nil, optionError("grid", fmt.Sprintf("%d x %d", cols, rows), "must be positive")
+	}
+	if face == nil {
+		face = basicfont.Face7x13
+	}
+
+	advance, ok := face.GlyphAdvance('M')
+	if !ok {
+		advance = face.Metrics().Height
+	}
+	cell := image.Rect(0, 0, advance.Ceil(), face.Metrics().Height.Ceil())
+
+	a := &GlyphAtlas{Cols: cols, Rows: rows, Glyphs: charset, Bitmaps: make([][]float32, len(charset))}
+	for i, char := range charset {
+		mask := image.NewAlpha(cell)
+		d := &font.Drawer{Dst: mask, Src: image.Opaque, Face: face, Dot: fixed.P(0, face.Metrics().Ascent.Ceil())}
+		d.DrawString(string(char))
+
+		a.Bitmaps[i] = make([]float32, cols*rows)
+		sampleGrid(mask.Pix, mask.Stride, cell, cols, rows, a.Bitmaps[i])
+	}
+	return a, nil
+}
+
+// Average the 8-bit values of rect, relative to the start of pix, over a cols x rows grid into dst. Sub-cells
+// split rect as evenly as whole pixels allow and cover at least one pixel each.
+func sampleGrid(pix []uint8, stride int, rect image.Rectangle, cols, rows int, dst []float32) {
+	w, h := rect.Dx(), rect.Dy()
+	for j := range rows {
+		y0 := rect.Min.Y + j*h/rows
+		y1 := max(rect.Min.Y+(j+1)*h/rows, y0+1)
+		for i := range cols {
+			x0 := rect.Min.X + i*w/cols
+			x1 := max(rect.Min.X+(i+1)*w/cols, x0+1)
+
+			sum := 0
+			for y := y0; y < min(y1, rect.Max.Y); y++ {
+				for x := x0; x < min(x1, rect.Max.X); x++ {
+					sum += int(pix[y*stride+x])
+				}
+			}
+			dst[j*cols+i] = 0
+			n := (min(y1, rect.Max.Y) - y0) * (min(x1, rect.Max.X) - x0)
+			if n > 0 {
+				dst[j*cols+i] = float32(sum) / float32(255*n)
+			}
+		}
+	}
+}
+
+// Glyph whose bitmap is closest to a cell's Cols x Rows samples (0 to 1, brighter meaning more ink) under metric,
+// the earliest in the charset on ties
+func (a *GlyphAtlas) Match(cell []float32, metric ShapeMetric) rune {
+	return a.match(cell, metric, a.coverage())
+}
+
+func (a *GlyphAtlas) match(cell []float32, metric ShapeMetric, coverage float32) rune {
+	best := 0
+	bestDistance := float32(math.Inf(1))
+	for i, bitmap := range a.Bitmaps {
+		var d float32
+		switch metric {
+		case SSIM:
+			d = 1 - ssim(cell, bitmap)
+		default:
+			d = l2(cell, bitmap, coverage)
+		}
+		if d < bestDistance {
+			best, bestDistance = i, d
+		}
+	}
+	return a.Glyphs[best]
+}
+
+// Mean coverage of the inkiest glyph, which a fully bright cell is drawn with. An atlas without ink has 1.
+func (a *GlyphAtlas) coverage() float32 {
+	var coverage float32
+	for _, bitmap := range a.Bitmaps {
+		mean, _ := meanStdDev(bitmap)
+		coverage = max(coverage, mean)
+	}
+	if coverage == 0 {
+		return 1
+	}
+	return coverage
+}
+
+// Squared distance of a cell's samples, with their brightness scaled onto the glyphs' coverage, from a glyph's bitmap,
+// split into the difference of their means and of their patterns about the means, with the glyph's contrast scaled
+// to the cell's. Raw values would draw nearly every cell with the inkiest glyph, since none covers more than part of
+// a cell; this way flat cells match on tone alone and cells with detail also on how well the pattern lines up.
+func l2(cell, bitmap []float32, coverage float32) float32 {
+	cellMean, cellStdDev := meanStdDev(cell)
+	glyphMean, glyphStdDev := meanStdDev(bitmap)
+
+	scale := float32(0)
+	if glyphStdDev > 0 {
+		scale = cellStdDev * coverage / glyphStdDev
+	}
+
+	tone := cellMean*coverage - glyphMean
+	d := float32(len(cell)) * tone * tone
+	for i := range cell {
+		diff := (cell[i]-cellMean)*coverage - (bitmap[i]-glyphMean)*scale
+		d += diff * diff
+	}
+	return d
+}
+
+func meanStdDev(x []float32) (mean, stdDev float32) {
+	for _, v := range x {
+		mean += v
+	}
+	mean /= float32(len(x))
+	for _, v := range x {
+		stdDev += (v - mean) * (v - mean)
+	}
+	return mean, float32(math.Sqrt(float64(stdDev / float32(len(x)))))
+}
+
+// Structural similarity of two equally long sample vectors with values from 0 to 1, taken over the whole vector
+func ssim(x, y []float32) float32 {
+	const c1 = 0.01 * 0.01
+	const c2 = 0.03 * 0.03
+
+	n := float32(len(x))
+	var meanX, meanY float32
+	for i := range x {
+		meanX += x[i]
+		meanY += y[i]
+	}
+	meanX /= n
+	meanY /= n
+
+	var varX, varY, cov float32
+	for i := range x {
+		dx, dy := x[i]-meanX, y[i]-meanY
+		varX += dx * dx
+		varY += dy * dy
+		cov += dx * dy
+	}
+	varX /= n
+	varY /= n
+	cov /= n
+
+	return (2*meanX*meanY + c1) * (2*cov + c2) / ((meanX*meanX + meanY*meanY + c1) * (varX + varY + c2))
+}
+
+// Convert an image to ASCII art by shape: every cell of the same grid as GrayDownscale is sampled at the atlas's
+// sub-cell resolution and drawn with the glyph that matches it best under metric
+func MatchShapes(img image.Image, atlas *GlyphAtlas, width int, squash float32, metric ShapeMetric) ([][]rune, error) {
+	return matchShapes(img, atlas, width, squash, metric, 0, nil)
+}
+
+func matchShapes(img image.Image, atlas *GlyphAtlas, width int, squash float32, metric ShapeMetric, workers int, s *stage) ([][]rune, error) {
+	if atlas == nil {
+		return nil, optionError("atlas", nil, "must not be nil")
+	}
+	if width <= 0 {
+		return nil, optionError("width", width, "must be positive")
+	}
+	if squash <= 0 {
+		return nil, optionError("squash", squash, "must be positive")
+	}
+	if metric != L2 && metric != SSIM {
+		return nil, optionError("metric", metric, "unknown shape metric")
+	}
+
+	gray := grayscale(img, workers)
+	origin := gray.Rect.Min
+	r := newResult(gray.Rect, width, squash)
+	height := int(math.Floor(float64(gray.Rect.Dy()) / r.YScale))
+
+	coverage := atlas.coverage()
+	art := make([][]rune, height)
+	err := s.rows(height, workers, func(y int) {
+		art[y] = make([]rune, width)
+		samples := make([]float32, atlas.Cols*atlas.Rows)
+		for x := range width {
+			sampleGrid(gray.Pix, gray.Stride, r.Bounds(x, y).Sub(origin), atlas.Cols, atlas.Rows, samples)
+			art[y][x] = atlas.match(samples, metric, coverage)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return art, nil
+}
@@ -0,0 +1,151 @@
+package asciiart
+
+import (
+	"image"
+	"slices"
+	"testing"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+func TestParseShapeMetric(t *testing.T) {
+	testData := []struct {
+		name    string
+		want    ShapeMetric
+		wantErr bool
+	}{
+		{"", L2, false},
+		{"l2", L2, false},
+		{"ssim", SSIM, false},
+		{"l1", L2, true},
+	}
+
+	for _, d := range testData {
+		got, err := ParseShapeMetric(d.name)
+		if (err != nil) != d.wantErr {
+			t.Errorf("%q: got error %v, want error %t", d.name, err, d.wantErr)
+		}
+		if got != d.want {
+			t.Errorf("%q: got %v, want %v", d.name, got, d.want)
+		}
+		if !d.wantErr && d.name != "" && got.String() != d.name {
+			t.Errorf("%q: got name %q", d.name, got.String())
+		}
+	}
+}
+
+func TestNewGlyphAtlas(t *testing.T) {
+	a, err := NewGlyphAtlas(nil, []rune(" |_"), 4, 6)
+	if err != nil {
+		t.Fatalf("Error building atlas: %v", err)
+	}
+
+	var inkiest float32
+	for _, v := range a.Bitmaps[0] {
+		if v != 0 {
+			t.Fatalf("space has ink: %v", a.Bitmaps[0])
+		}
+	}
+	for _, bitmap := range a.Bitmaps {
+		for _, v := range bitmap {
+			inkiest = max(inkiest, v)
+		}
+	}
+	if inkiest <= 0 || inkiest > 1 {
+		t.Errorf("got inkiest sub-cell %v, want coverage from 0 to 1", inkiest)
+	}
+
+	// A bar runs down the middle columns, an underscore along the bottom row
+	sum := func(bitmap []float32, keep func(i, j int) bool) float32 {
+		var s float32
+		for j := range a.Rows {
+			for i := range a.Cols {
+				if keep(i, j) {
+					s += bitmap[j*a.Cols+i]
+				}
+			}
+		}
+		return s
+	}
+	bar := a.Bitmaps[1]
+	if inner, outer := sum(bar, func(i, j int) bool { return i == 1 || i == 2 }), sum(bar, func(i, j int) bool { return i == 0 || i == 3 }); inner <= outer {
+		t.Errorf("bar has %v ink in its middle columns and %v outside: %v", inner, outer, bar)
+	}
+	underscore := a.Bitmaps[2]
+	if bottom, total := sum(underscore, func(i, j int) bool { return j == a.Rows-1 }), sum(underscore, func(i, j int) bool { return true }); bottom < total/2 {
+		t.Errorf("underscore has %v of its %v ink in the bottom row: %v", bottom, total, underscore)
+	}
+
+	if _, err := NewGlyphAtlas(nil, nil, 4, 6); err == nil {
+		t.Error("expected error for empty charset")
+	}
+	if _, err := NewGlyphAtlas(nil, []rune("ab"), 0, 6); err == nil {
+		t.Error("expected error for empty grid")
+	}
+}
+
+func TestMatchShapes(t *testing.T) {
+	charset := []rune(" |-_/\\")
+	a, err := NewGlyphAtlas(nil, charset, 4, 6)
+	if err != nil {
+		t.Fatalf("Error building atlas: %v", err)
+	}
+
+	// Every glyph is its own closest match, under L2 once scaled up to the brightness its ink stands for
+	coverage := a.coverage()
+	for i, bitmap := range a.Bitmaps {
+		bright := make([]float32, len(bitmap))
+		for j, v := range bitmap {
+			bright[j] = v / coverage
+		}
+		if got := a.Match(bright, L2); got != charset[i] {
+			t.Errorf("metric %s: %q matched %q", L2, charset[i], got)
+		}
+		if got := a.Match(bitmap, SSIM); got != charset[i] {
+			t.Errorf("metric %s: %q matched %q", SSIM, charset[i], got)
+		}
+	}
+
+	// Under L2 flat cells match on tone alone, from blank for black to the inkiest glyph for white
+	tones, err := NewGlyphAtlas(nil, []rune(" .:=#@"), 4, 6)
+	if err != nil {
+		t.Fatalf("Error building atlas: %v", err)
+	}
+	flat := make([]float32, tones.Cols*tones.Rows)
+	prev := -1
+	for _, level := range []float32{0, 0.2, 0.4, 0.6, 0.8, 1} {
+		for j := range flat {
+			flat[j] = level
+		}
+		got := slices.Index(tones.Glyphs, tones.Match(flat, L2))
+		if got < prev {
+			t.Errorf("level %v: got %q, darker than %q", level, tones.Glyphs[got], tones.Glyphs[prev])
+		}
+		prev = got
+		if level == 0 && got != 0 || level == 1 && got != len(tones.Glyphs)-1 {
+			t.Errorf("level %v: got %q", level, tones.Glyphs[got])
+		}
+	}
+
+	// Text drawn with the atlas's own font in cells of the font's size reads back as the same text under SSIM, which
+	// doesn't care that thin white strokes on black only add up to a dark cell
+	want := "/|\\-_ |"
+	face := basicfont.Face7x13
+	img := image.NewGray(image.Rect(0, 0, 7*len(want), 13))
+	d := &font.Drawer{Dst: img, Src: image.White, Face: face, Dot: fixed.P(0, face.Metrics().Ascent.Ceil())}
+	d.DrawString(want)
+
+	art, err := MatchShapes(img, a, len(want), 13.0/7, SSIM)
+	if err != nil {
+		t.Fatalf("Error matching shapes: %v", err)
+	}
+	if len(art) != 1 || string(art[0]) != want {
+		t.Errorf("got %q, want %q", art, want)
+	}
+
+	if _, err := MatchShapes(img, nil, 7, 2, L2); err == nil {
+		t.Error("expected error for nil atlas")
+	}
+}
@@ -0,0 +1,115 @@
+package asciiart
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"image/color"
+	"io"
+	"math"
+	"strconv"
+)
+
+// Renders the characters as an SVG document with one <text> element per row, each character placed at its own x on a
+// fixed cell grid so the layout doesn't depend on the font's advance
+type SVGRenderer struct {
+	FontFamily string      // font family of the text, defaults to monospace
+	FontSize   float64     // font size in user units, defaults to 12
+	CellWidth  float64     // horizontal advance per character, defaults to 0.6 of FontSize
+	CellHeight float64     // line height, defaults to FontSize
+	Background color.Color // fill behind the text, defaults to black; fully transparent colors omit the background
+	Foreground color.Color // text fill when not coloring cells, defaults to white
+	Color      bool        // fill runs of same-colored cells using the result's cell colors
+}
+
+func (s SVGRenderer) withDefaults() SVGRenderer {
+	if s.FontFamily == "" {
+		s.FontFamily = "monospace"
+	}
+	if s.FontSize <= 0 {
+		s.FontSize = 12
+	}
+	if s.CellWidth <= 0 {
+		s.CellWidth = 0.6 * s.FontSize
+	}
+	if s.CellHeight <= 0 {
+		s.CellHeight = s.FontSize
+	}
+	if s.Background == nil {
+		s.Background = color.Black
+	}
+	if s.Foreground == nil {
+		s.Foreground = color.White
+	}
+	return s
+}
+
+func (s SVGRenderer) Render(w io.Writer, r *Result) error {
+	if s.Color {
+		if _, err := resultColors(r); err != nil {
+			return err
+		}
+	}
+	s = s.withDefaults()
+
+	width := float64(r.Width()) * s.CellWidth
+	height := float64(r.Height()) * s.CellHeight
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%s\" height=\"%s\" viewBox=\"0 0 %[1]s %[2]s\">\n", svgNum(width), svgNum(height))
+
+	if _, _, _, a := s.Background.RGBA(); a != 0 {
+		fmt.Fprintf(bw, "<rect width=\"100%%\" height=\"100%%\"%s/>\n", svgFill(s.Background))
+	}
+
+	bw.WriteString("<g font-family=\"")
+	xml.EscapeText(bw, []byte(s.FontFamily))
+	fmt.Fprintf(bw, "\" font-size=\"%s\"%s xml:space=\"preserve\">\n", svgNum(s.FontSize), svgFill(s.Foreground))
+
+	for y, row := range r.Art {
+		// Place the baseline a fifth of the cell above its bottom edge to leave room for descenders
+		fmt.Fprintf(bw, "<text y=\"%s\">", svgNum((float64(y)+0.8)*s.CellHeight))
+		if s.Color {
+			colorRuns(r, y, func(start, end int, c color.RGBA) {
+				fmt.Fprintf(bw, "<tspan x=\"%s\" fill=\"%s\">", s.cellXs(start, end), hexColor(c))
+				xml.EscapeText(bw, []byte(string(row[start:end])))
+				bw.WriteString("</tspan>")
+			})
+		} else {
+			fmt.Fprintf(bw, "<tspan x=\"%s\">", s.cellXs(0, len(row)))
+			xml.EscapeText(bw, []byte(string(row)))
+			bw.WriteString("</tspan>")
+		}
+		bw.WriteString("</text>\n")
+	}
+
+	bw.WriteString("</g>\n</svg>\n")
+	return bw.Flush()
+}
+
+// Space separated left edges of the cells from start up to end, one per character
+func (s SVGRenderer) cellXs(start, end int) string {
+	xs := make([]byte, 0, 6*(end-start))
+	for x := start; x < end; x++ {
+		if x > start {
+			xs = append(xs, ' ')
+		}
+		xs = append(xs, svgNum(float64(x)*s.CellWidth)...)
+	}
+	return string(xs)
+}
+
+// Formats a length to three decimals without trailing zeros
+func svgNum(v float64) string {
+	return strconv.FormatFloat(math.Round(v*1000)/1000, 'f', -1, 64)
+}
+
+// Fill attributes for c, with an opacity only for translucent colors
+func svgFill(c color.Color) string {
+	n := color.NRGBAModel.Convert(c).(color.NRGBA)
+	fill := fmt.Sprintf(" fill=\"%s\"", hexColor(color.RGBA{n.R, n.G, n.B, 255}))
+	if n.A != 255 {
+		fill += fmt.Sprintf(" fill-opacity=\"%s\"", svgNum(float64(n.A)/255))
+	}
+	return fill
+}
@@ -0,0 +1,91 @@
+package asciiart
+
+import (
+	"bytes"
+	"encoding/xml"
+	"image"
+	"image/color"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestSVGRenderer(t *testing.T) {
+	r := &Result{Art: [][]rune{[]rune("<&>"), []rune("\\ |")}}
+
+	var buf bytes.Buffer
+	err := SVGRenderer{FontSize: 10, Background: color.Transparent}.Render(&buf, r)
+	if err != nil {
+		t.Fatalf("Failed to render: %v", err)
+	}
+
+	res := buf.String()
+	for _, want := range []string{
+		"width=\"18\" height=\"20\" viewBox=\"0 0 18 20\"",
+		"font-family=\"monospace\" font-size=\"10\" fill=\"#ffffff\"",
+		"<text y=\"8\"><tspan x=\"0 6 12\">&lt;&amp;&gt;</tspan></text>",
+		"<text y=\"18\"><tspan x=\"0 6 12\">\\ |</tspan></text>",
+	} {
+		if !strings.Contains(res, want) {
+			t.Errorf("output %q does not contain %q", res, want)
+		}
+	}
+	if strings.Contains(res, "<rect") {
+		t.Errorf("output %q has a background with a transparent fill", res)
+	}
+
+	// The document has to be well-formed XML
+	d := xml.NewDecoder(strings.NewReader(res))
+	for {
+		_, err := d.Token()
+		if err != nil {
+			if err != io.EOF {
+				t.Errorf("Invalid XML: %v", err)
+			}
+			break
+		}
+	}
+}
+
+func TestSVGRendererCellSize(t *testing.T) {
+	r := &Result{Art: [][]rune{[]rune("abcd"), []rune("efgh")}}
+
+	// Cells wider and taller than the font's own advance and line still put every character on the grid
+	var buf bytes.Buffer
+	err := SVGRenderer{FontSize: 10, CellWidth: 7.5, CellHeight: 15}.Render(&buf, r)
+	if err != nil {
+		t.Fatalf("Failed to render: %v", err)
+	}
+
+	for _, want := range []string{
+		"width=\"30\" height=\"30\" viewBox=\"0 0 30 30\"",
+		"<text y=\"12\"><tspan x=\"0 7.5 15 22.5\">abcd</tspan></text>",
+		"<text y=\"27\"><tspan x=\"0 7.5 15 22.5\">efgh</tspan></text>",
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("output %q does not contain %q", buf.String(), want)
+		}
+	}
+}
+
+func TestSVGRendererColor(t *testing.T) {
+	colors := image.NewRGBA(image.Rect(0, 0, 3, 1))
+	colors.SetRGBA(0, 0, color.RGBA{255, 0, 0, 255})
+	colors.SetRGBA(1, 0, color.RGBA{0, 255, 0, 255})
+	colors.SetRGBA(2, 0, color.RGBA{0, 255, 0, 255})
+	r := &Result{Art: [][]rune{[]rune("ab ")}, Colors: colors}
+
+	var buf bytes.Buffer
+	err := SVGRenderer{CellWidth: 5, Color: true}.Render(&buf, r)
+	if err != nil {
+		t.Fatalf("Failed to render: %v", err)
+	}
+
+	want := "<tspan x=\"0\" fill=\"#ff0000\">a</tspan><tspan x=\"5 10\" fill=\"#00ff00\">b </tspan>"
+	if !strings.Contains(buf.String(), want) {
+		t.Errorf("output %q does not contain %q", buf.String(), want)
+	}
+	if !strings.Contains(buf.String(), "<rect width=\"100%\" height=\"100%\" fill=\"#000000\"/>") {
+		t.Errorf("output %q is missing the default background", buf.String())
+	}
+}
@@ -2,4 +2,7 @@ module github.com/jeffc25/asciiart
 
 go 1.23.1
 
-require github.com/disintegration/gift v1.2.1
+require (
+	github.com/disintegration/gift v1.2.1
+	golang.org/x/image v0.25.0
+)
@@ -1,20 +1,27 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"flag"
 	"fmt"
-	"image"
+	"image/color"
 	_ "image/jpeg"
 	_ "image/png"
+	"io"
 	"log"
+	"log/slog"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
 
 	"github.com/jeffc25/asciiart/asciiart"
 )
 
 func main() {
 	flag.Usage = func() {
-		fmt.Fprintf(os.Stderr, "Usage: asciiart [options] <file>\n\nConverts an image to ASCII art.\n\nOptions:\n")
+		fmt.Fprintf(os.Stderr, "Usage: asciiart [options] <file>\n       asciiart -play [options] <file>...\n\nConverts an image or animated GIF to ASCII art, or plays a GIF or sequence of images in the terminal.\n\nOptions:\n")
 		flag.PrintDefaults()
 	}
 
@@ -25,32 +32,164 @@ func main() {
 	epsilon := flag.Float64("epsilon", 0.65, "epsilon for DoG")
 	tau := flag.Float64("tau", 0.8, "tau for DoG")
 	phi := flag.Float64("phi", 25, "phi for DoG")
-	sThreshold := flag.Float64("sthres", 0.15, "minimum threshold for sobel filter")
+	k := flag.Float64("k", 0, "ratio of the second gaussian's sigma to the first, used instead of -s2 when set")
+	dogForm := flag.String("dog-form", "sharpened", "DoG formulation: sharpened ((1+tau)*G1 - tau*G2) or difference (G1 - tau*G2)")
+	dogThreshold := flag.String("dog-threshold", "soft", "DoG thresholding: soft, hard or quantized")
+	levels := flag.Int("levels", 4, "gray levels for -dog-threshold quantized")
+	invert := flag.Bool("invert", false, "invert the DoG output")
+	flow := flag.Bool("flow", false, "apply the DoG along the edge tangent flow (FDoG), which keeps curved lines connected")
+	tensorSigma := flag.Float64("tensor-sigma", 2, "sigma for smoothing the structure tensor of the FDoG edge tangent flow")
+	flowSigma := flag.Float64("flow-sigma", 3, "sigma for the FDoG blur along the edge tangent flow")
+	edgeOperator := flag.String("edge-operator", "sobel", "gradient operator for edge detection: sobel, scharr, prewitt or roberts")
+	sThreshold := flag.Float64("sthres", 0.15, "minimum threshold (0 to 1) for the edge operator's gradient magnitude")
 	eThreshold := flag.Float64("ethres", 0.05, "minimum edge density in a downscaled block")
 	squash := flag.Float64("squash", 2.3, "factor to compress output height to offset aspect ratio differences between ascii and pixels")
+	canny := flag.Bool("canny", false, "detect edges with Canny (thin edges, consider a lower -ethres) instead of thresholding the gradient magnitude")
+	cLow := flag.Float64("clow", 0.05, "low hysteresis threshold for Canny edge detection")
+	cHigh := flag.Float64("chigh", 0.15, "high hysteresis threshold for Canny edge detection")
+	bins := flag.Int("bins", 4, "number of edge orientation classes: 4, 8 or 16")
+	glyphSet := flag.String("glyphset", "ascii", "glyphs to draw edge orientations with: ascii or unicode")
+	edgeGlyphs := flag.String("edge-glyphs", "", "comma separated name=glyph pairs overriding the -glyphset glyphs, e.g. \"horizontal=─,vertical=│\"")
+	connect := flag.String("connect", "none", "join edges where horizontal and vertical strokes meet: none, box (┌┬┼ etc.) or ascii (+)")
+	neighbors := flag.Int("neighbors", 4, "neighborhood in which -connect joins edges: 4, or 8 to include diagonal cells")
+	corners := flag.Bool("corners", false, "detect corners and draw them with the corner glyph")
+	cornerMethod := flag.String("corner-method", "harris", "corner response: harris or shi-tomasi")
+	cornerSigma := flag.Float64("corner-sigma", 1.5, "sigma of the Gaussian window for corner detection")
+	cornerK := flag.Float64("corner-k", 0.04, "sensitivity of the Harris corner response")
+	cornerThreshold := flag.Float64("corner-threshold", 0.1, "minimum corner response (0 to 1) as a fraction of the strongest")
+	weighted := flag.Bool("weighted", false, "weight each pixel's vote for its cell's edge, and the -ethres density, by gradient magnitude")
+	circular := flag.Bool("circular", false, "orient each cell by the magnitude weighted circular mean of its edge angles (implies -weighted)")
+	workers := flag.Int("workers", 0, "goroutines for the pixel-level stages (0 for one per CPU)")
 	noEdges := flag.Bool("noedges", false, "convert without edge detection")
 	noBase := flag.Bool("nobase", false, "convert without base ascii luminance mapping")
 	noDoG := flag.Bool("nodog", false, "convert without Difference of Gaussians preprocessing for edge detection")
+	colorMode := flag.String("color", "none", "color output mode: none, truecolor, 256 or 16")
+	shape := flag.Bool("shape", false, "pick each character by matching the glyph shapes of the charset instead of luminance and edges")
+	shapeMetric := flag.String("shape-metric", "l2", "distance for shape matching: l2 or ssim")
+	shapeCols := flag.Int("shape-cols", 4, "columns of the sub-cell grid for shape matching")
+	shapeRows := flag.Int("shape-rows", 6, "rows of the sub-cell grid for shape matching")
+	dither := flag.String("dither", "none", "dithering of luminance before mapping to the charset: none, floyd-steinberg, atkinson, jjn or bayer")
+	colorDither := flag.Bool("colordither", false, "apply Floyd-Steinberg dithering when quantizing to 256 or 16 colors")
+	format := flag.String("format", "", "output format: text, ansi, html, svg, png, jpeg or cast (default text, or ansi when -color is set)")
+	fragment := flag.Bool("fragment", false, "emit only the <pre> element for html output")
+	fontFamily := flag.String("fontfamily", "monospace", "font family for svg output")
+	fontSize := flag.Float64("fontsize", 12, "font size for svg output")
+	background := flag.String("bg", "#000000", "background color for svg and image output, as #rrggbb or #rrggbbaa")
+	foreground := flag.String("fg", "#ffffff", "text color for svg and image output without -color, as #rrggbb or #rrggbbaa")
+	output := flag.String("o", "", "file to write output to (default stdout)")
+	play := flag.Bool("play", false, "play the frames of an animated GIF, or the given images in order, in the terminal")
+	loops := flag.Int("loops", -1, "times to play or record the frames, 0 to repeat forever (default from the GIF, or once)")
+	maxFPS := flag.Float64("maxfps", 30, "maximum frames per second during playback and recording, 0 for no cap")
+	verbose := flag.Bool("v", false, "log the start of every conversion stage as well as its timing")
+	quiet := flag.Bool("quiet", false, "log nothing but errors")
 
 	flag.Parse()
 
-	if flag.NArg() != 1 {
+	if flag.NArg() < 1 || flag.NArg() > 1 && !*play {
 		flag.Usage()
 		os.Exit(2)
 	}
 
-	file, err := os.Open(flag.Arg(0))
+	if *verbose && *quiet {
+		log.Fatalf("Cannot use -v and -quiet together\n")
+	}
+	logger := slog.New(slog.NewTextHandler(os.Stderr, nil))
+	switch {
+	case *verbose:
+		logger = slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	case *quiet:
+		logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+	}
+
+	mode, err := asciiart.ParseColorMode(*colorMode)
+	if err != nil {
+		log.Fatalf("Invalid color mode: %v\n", err)
+	}
+
+	metric, err := asciiart.ParseShapeMetric(*shapeMetric)
+	if err != nil {
+		log.Fatalf("Invalid shape metric: %v\n", err)
+	}
+
+	ditherMethod, err := asciiart.ParseDitherMethod(*dither)
+	if err != nil {
+		log.Fatalf("Invalid dither method: %v\n", err)
+	}
+
+	detector, err := asciiart.ParseEdgeDetector(*edgeOperator)
+	if err != nil {
+		log.Fatalf("Invalid edge operator: %v\n", err)
+	}
+
+	glyphs, err := asciiart.ParseEdgeGlyphs(*glyphSet)
+	if err != nil {
+		log.Fatalf("Invalid glyph set: %v\n", err)
+	}
+
+	glyphs, err = asciiart.ParseEdgeGlyphSpec(*edgeGlyphs, glyphs)
+	if err != nil {
+		log.Fatalf("Invalid edge glyphs: %v\n", err)
+	}
+
+	form, err := asciiart.ParseDoGForm(*dogForm)
+	if err != nil {
+		log.Fatalf("Invalid DoG form: %v\n", err)
+	}
+
+	thresholdMode, err := asciiart.ParseThresholdMode(*dogThreshold)
+	if err != nil {
+		log.Fatalf("Invalid DoG threshold: %v\n", err)
+	}
+
+	method, err := asciiart.ParseCornerMethod(*cornerMethod)
+	if err != nil {
+		log.Fatalf("Invalid corner method: %v\n", err)
+	}
+
+	var junctions asciiart.JunctionGlyphs
+	if *connect != "none" {
+		junctions, err = asciiart.ParseJunctionGlyphs(*connect)
+		if err != nil {
+			log.Fatalf("Invalid junction glyphs: %v\n", err)
+		}
+	}
+
+	bg, err := parseHexColor(*background)
+	if err != nil {
+		log.Fatalf("Invalid background color: %v\n", err)
+	}
+
+	fg, err := parseHexColor(*foreground)
+	if err != nil {
+		log.Fatalf("Invalid foreground color: %v\n", err)
+	}
+
+	// Recordings hold terminal output, rendered as text or ANSI depending on -color
+	cast := *format == "cast"
+	rendererFormat := *format
+	if cast {
+		rendererFormat = ""
+	}
+
+	renderer, err := newRenderer(rendererFormat, renderOptions{
+		mode:       mode,
+		dither:     *colorDither,
+		fragment:   *fragment,
+		fontFamily: *fontFamily,
+		fontSize:   *fontSize,
+		background: bg,
+		foreground: fg,
+	})
 	if err != nil {
-		log.Fatalf("Failed to open file: %v\n", err)
+		log.Fatalf("Invalid output format: %v\n", err)
 	}
-	defer file.Close()
 
-	img, _, err := image.Decode(file)
+	anim, err := loadAnimation(flag.Args())
 	if err != nil {
-		log.Fatalf("Failed to decode image: %v", err)
+		log.Fatalf("Failed to load image: %v\n", err)
 	}
 
-	c := asciiart.NewConverter(img,
+	c := asciiart.NewConverter(anim.Frames[0].Img,
 		asciiart.WithCharset([]rune(*charset)),
 		asciiart.WithWidth(*charwidth),
 		asciiart.WithDSigma1(float32(*sigma1)),
@@ -58,18 +197,208 @@ func main() {
 		asciiart.WithDEpsilon(float32(*epsilon)),
 		asciiart.WithDTau(float32(*tau)),
 		asciiart.WithDPhi(float32(*phi)),
+		asciiart.WithDK(float32(*k)),
+		asciiart.WithDForm(form),
+		asciiart.WithDThreshold(thresholdMode),
+		asciiart.WithDLevels(*levels),
+		asciiart.WithDInvert(*invert),
+		asciiart.WithDoFlow(*flow),
+		asciiart.WithFTensorSigma(float32(*tensorSigma)),
+		asciiart.WithFFlowSigma(float32(*flowSigma)),
+		asciiart.WithEdgeDetector(detector),
 		asciiart.WithSThreshold(float32(*sThreshold)),
 		asciiart.WithEThreshold(float32(*eThreshold)),
 		asciiart.WithSquash(float32(*squash)),
+		asciiart.WithDoCanny(*canny),
+		asciiart.WithCLow(float32(*cLow)),
+		asciiart.WithCHigh(float32(*cHigh)),
+		asciiart.WithOrientationBins(*bins),
+		asciiart.WithEdgeGlyphs(glyphs),
+		asciiart.WithDoConnect(*connect != "none"),
+		asciiart.WithJunctions(junctions),
+		asciiart.WithNeighbors(*neighbors),
+		asciiart.WithDoCorners(*corners),
+		asciiart.WithCornerMethod(method),
+		asciiart.WithCornerSigma(float32(*cornerSigma)),
+		asciiart.WithCornerK(float32(*cornerK)),
+		asciiart.WithCornerThreshold(float32(*cornerThreshold)),
+		asciiart.WithDoWeighted(*weighted),
+		asciiart.WithDoCircular(*circular),
+		asciiart.WithDoShape(*shape),
+		asciiart.WithShapeMetric(metric),
+		asciiart.WithShapeGrid(*shapeCols, *shapeRows),
+		asciiart.WithDither(ditherMethod),
+		asciiart.WithWorkers(*workers),
+		asciiart.WithLogger(logger),
 		asciiart.WithDoEdges(!*noEdges),
 		asciiart.WithDoBase(!*noBase),
 		asciiart.WithDoDoG(!*noDoG),
+		asciiart.WithDoColor(mode != asciiart.NoColor || *format == "ansi"),
 	)
 
-	a, err := c.Convert()
+	frames, err := c.ConvertAnimation(anim)
 	if err != nil {
 		log.Fatalf("Failed to convert to ascii: %v\n", err)
 	}
 
-	asciiart.PrintASCIIArt(a)
+	player := asciiart.Player{Renderer: renderer, Loops: anim.Loops, MaxFPS: *maxFPS}
+	if *loops >= 0 {
+		player.Loops = *loops
+	}
+
+	if *play {
+		switch renderer.(type) {
+		case asciiart.TextRenderer, asciiart.ANSIRenderer:
+		default:
+			log.Fatalf("Cannot play %s output in the terminal\n", *format)
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		err = player.Play(ctx, os.Stdout, frames)
+		if err != nil && !errors.Is(err, context.Canceled) {
+			log.Fatalf("Failed to play: %v\n", err)
+		}
+		return
+	}
+
+	out := os.Stdout
+	if *output != "" {
+		out, err = os.Create(*output)
+		if err != nil {
+			log.Fatalf("Failed to create output file: %v\n", err)
+		}
+	}
+
+	if cast {
+		err = player.Record(out, frames, filepath.Base(flag.Arg(0)))
+	} else {
+		err = writeFrames(out, renderer, frames, *format, logger)
+	}
+	if err != nil {
+		log.Fatalf("Failed to render output: %v\n", err)
+	}
+
+	err = out.Close()
+	if err != nil {
+		log.Fatalf("Failed to close output: %v\n", err)
+	}
+}
+
+// Render converted frames to out. Text output lists every frame of an animation, separated by blank lines;
+// other formats hold a single image, so only the first frame is rendered, with a warning to logger.
+func writeFrames(out io.Writer, renderer asciiart.Renderer, frames []asciiart.ResultFrame, format string, logger *slog.Logger) error {
+	switch renderer.(type) {
+	case asciiart.TextRenderer, asciiart.ANSIRenderer:
+	default:
+		if len(frames) > 1 {
+			logger.Warn("rendering only the first frame", "frames", len(frames), "format", format)
+			frames = frames[:1]
+		}
+	}
+
+	for i, f := range frames {
+		if i > 0 {
+			fmt.Fprintln(out)
+		}
+		err := renderer.Render(out, f.Result)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Decode the frames of every file in order into a single animation, which plays as many times as the first file
+func loadAnimation(paths []string) (*asciiart.Animation, error) {
+	var anim *asciiart.Animation
+	for _, path := range paths {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+
+		a, err := asciiart.DecodeAnimation(file)
+		file.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", path, err)
+		}
+
+		if anim == nil {
+			anim = a
+		} else {
+			anim.Frames = append(anim.Frames, a.Frames...)
+		}
+	}
+	return anim, nil
+}
+
+// Output settings shared by the renderers
+type renderOptions struct {
+	mode       asciiart.ColorMode
+	dither     bool
+	fragment   bool
+	fontFamily string
+	fontSize   float64
+	background color.Color
+	foreground color.Color
+}
+
+// Select the renderer for an output format name
+func newRenderer(format string, opts renderOptions) (asciiart.Renderer, error) {
+	mode := opts.mode
+	if format == "" {
+		format = "text"
+		if mode != asciiart.NoColor {
+			format = "ansi"
+		}
+	}
+
+	switch format {
+	case "text":
+		return asciiart.TextRenderer{}, nil
+	case "ansi":
+		if mode == asciiart.NoColor {
+			mode = asciiart.Truecolor
+		}
+		return asciiart.ANSIRenderer{Mode: mode, Dither: opts.dither}, nil
+	case "html":
+		return asciiart.HTMLRenderer{Fragment: opts.fragment, Color: mode != asciiart.NoColor, Title: filepath.Base(flag.Arg(0))}, nil
+	case "svg":
+		return asciiart.SVGRenderer{
+			FontFamily: opts.fontFamily,
+			FontSize:   opts.fontSize,
+			Background: opts.background,
+			Foreground: opts.foreground,
+			Color:      mode != asciiart.NoColor,
+		}, nil
+	case "png", "jpeg":
+		return asciiart.ImageRenderer{
+			Format:     format,
+			Background: opts.background,
+			Foreground: opts.foreground,
+			Color:      mode != asciiart.NoColor,
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown format: %q", format)
+	}
+}
+
+// Parse a #rrggbb or #rrggbbaa color
+func parseHexColor(s string) (color.NRGBA, error) {
+	c := color.NRGBA{A: 255}
+	var err error
+	switch len(s) {
+	case 7:
+		_, err = fmt.Sscanf(s, "#%02x%02x%02x", &c.R, &c.G, &c.B)
+	case 9:
+		_, err = fmt.Sscanf(s, "#%02x%02x%02x%02x", &c.R, &c.G, &c.B, &c.A)
+	default:
+		err = fmt.Errorf("expected #rrggbb or #rrggbbaa")
+	}
+	if err != nil {
+		return c, fmt.Errorf("%q: %v", s, err)
+	}
+	return c, nil
 }